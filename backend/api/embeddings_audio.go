@@ -0,0 +1,187 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/owulveryck/agentflowui/backend/models"
+	"github.com/owulveryck/agentflowui/backend/vertexai"
+)
+
+// speechContentTypes maps the response_format values we can actually
+// produce to their Content-Type. Gemini TTS only emits raw PCM -- it can't
+// be asked to encode mp3/opus/aac/flac, and we have no codec to transcode to
+// those ourselves, so only the formats vertexai.Synthesize/EncodeWAV can
+// genuinely deliver are listed here; any other OpenAI response_format value
+// is rejected rather than mislabeled.
+var speechContentTypes = map[string]string{
+	"wav": "audio/wav",
+	"pcm": "audio/pcm",
+}
+
+// HandleEmbeddings handles the /v1/embeddings endpoint
+func (h *Handler) HandleEmbeddings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.EmbeddingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Failed to decode embeddings request: %v", err)
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	inputs, err := normalizeEmbeddingsInput(req.Input)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := vertexai.Embed(r.Context(), h.vertexClient, req.Model, inputs)
+	if err != nil {
+		log.Printf("Embeddings error: %v", err)
+		http.Error(w, fmt.Sprintf("failed to generate embeddings: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Failed to encode embeddings response: %v", err)
+		http.Error(w, "error marshaling response", http.StatusInternalServerError)
+	}
+}
+
+// normalizeEmbeddingsInput accepts OpenAI's string | []string input shape
+func normalizeEmbeddingsInput(input interface{}) ([]string, error) {
+	switch v := input.(type) {
+	case string:
+		return []string{v}, nil
+	case []interface{}:
+		inputs := make([]string, len(v))
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid input at index %d: expected string", i)
+			}
+			inputs[i] = s
+		}
+		return inputs, nil
+	default:
+		return nil, fmt.Errorf("invalid input type %T: expected string or array of strings", input)
+	}
+}
+
+// HandleTranscriptions handles the /v1/audio/transcriptions endpoint
+func (h *Handler) HandleTranscriptions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		http.Error(w, fmt.Sprintf("invalid multipart request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	model := r.FormValue("model")
+	language := r.FormValue("language")
+
+	audioData, mimeType, err := h.resolveAudioInput(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := vertexai.Transcribe(r.Context(), h.vertexClient, model, audioData, mimeType, language)
+	if err != nil {
+		log.Printf("Transcription error: %v", err)
+		http.Error(w, fmt.Sprintf("failed to transcribe audio: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		log.Printf("Failed to encode transcription response: %v", err)
+		http.Error(w, "error marshaling response", http.StatusInternalServerError)
+	}
+}
+
+// resolveAudioInput extracts the audio bytes and MIME type for a
+// transcription request, accepting either a direct multipart "file" upload
+// or a "file_url" form field resolved through the storage registry, so
+// gdrive:// and other managed URLs work here too.
+func (h *Handler) resolveAudioInput(r *http.Request) ([]byte, string, error) {
+	if fileURL := r.FormValue("file_url"); fileURL != "" {
+		data, mimeType, resolved, err := resolveStorageRef(r, h.storage, fileURL, h.maxFileSize)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to fetch file_url: %w", err)
+		}
+		if !resolved {
+			return nil, "", fmt.Errorf("file_url %q is not a recognized storage reference", fileURL)
+		}
+		return data, mimeType, nil
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		return nil, "", fmt.Errorf("missing audio file: %w", err)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read audio file: %w", err)
+	}
+
+	mimeType := header.Header.Get("Content-Type")
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+
+	return data, mimeType, nil
+}
+
+// HandleSpeech handles the /v1/audio/speech endpoint
+func (h *Handler) HandleSpeech(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req models.SpeechRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Failed to decode speech request: %v", err)
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	responseFormat := req.ResponseFormat
+	if responseFormat == "" {
+		responseFormat = "wav"
+	}
+	contentType, ok := speechContentTypes[responseFormat]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unsupported response_format %q: only wav and pcm are supported, since Gemini TTS only emits raw PCM and this service doesn't transcode to other formats", responseFormat), http.StatusBadRequest)
+		return
+	}
+
+	pcm, err := vertexai.Synthesize(r.Context(), h.vertexClient, req.Model, req.Input, req.Voice)
+	if err != nil {
+		log.Printf("Speech synthesis error: %v", err)
+		http.Error(w, fmt.Sprintf("failed to synthesize speech: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	audioData := pcm
+	if responseFormat == "wav" {
+		audioData = vertexai.EncodeWAV(pcm)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(audioData)
+}
@@ -2,33 +2,55 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strings"
 
-	"github.com/owulveryck/agentflowui/backend/gdrive"
+	"github.com/owulveryck/agentflowui/backend/auth"
+	"github.com/owulveryck/agentflowui/backend/cache"
 	"github.com/owulveryck/agentflowui/backend/models"
+	"github.com/owulveryck/agentflowui/backend/providers"
+	"github.com/owulveryck/agentflowui/backend/storage"
 	"github.com/owulveryck/agentflowui/backend/vertexai"
 	"google.golang.org/genai"
 )
 
 // Handler handles HTTP requests
 type Handler struct {
-	vertexClient *genai.Client
-	gdriveClient *gdrive.Client
-	modelNames   []string
+	vertexClient     *genai.Client // used directly by the embeddings/audio endpoints, which aren't routed
+	router           *providers.Router
+	storage          *storage.Registry
+	modelNames       []string
+	maxFileSize      int64    // reject fetched multimodal parts larger than this, in bytes; 0 means unlimited
+	allowedMimeTypes []string // allowed MIME types for fetched multimodal parts; empty means "allow all"
+
+	completionCache *cache.Cache // non-streaming response cache; nil disables caching
+	embeddingModel  string       // model used to embed the last user turn for semantic cache lookups
 }
 
 // NewHandler creates a new HTTP handler
-func NewHandler(vertexClient *genai.Client, gdriveClient *gdrive.Client, modelNames []string) *Handler {
+func NewHandler(vertexClient *genai.Client, router *providers.Router, storageRegistry *storage.Registry, modelNames []string, maxFileSize int64, allowedMimeTypes []string) *Handler {
 	return &Handler{
-		vertexClient: vertexClient,
-		gdriveClient: gdriveClient,
-		modelNames:   modelNames,
+		vertexClient:     vertexClient,
+		router:           router,
+		storage:          storageRegistry,
+		modelNames:       modelNames,
+		maxFileSize:      maxFileSize,
+		allowedMimeTypes: allowedMimeTypes,
 	}
 }
 
+// WithCompletionCache enables response caching for non-streaming chat
+// completions. embeddingModel is used to embed the last user turn when the
+// cache is configured for semantic similarity lookups.
+func (h *Handler) WithCompletionCache(completionCache *cache.Cache, embeddingModel string) *Handler {
+	h.completionCache = completionCache
+	h.embeddingModel = embeddingModel
+	return h
+}
+
 // HandleChatCompletion handles the /v1/chat/completions endpoint
 func (h *Handler) HandleChatCompletion(w http.ResponseWriter, r *http.Request) {
 	// Only accept POST requests
@@ -45,53 +67,190 @@ func (h *Handler) HandleChatCompletion(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Extract Google Drive auth token from header
-	gdriveToken := r.Header.Get("X-Google-Drive-Token")
-
-	// Check if any messages contain gdrive:// URLs
-	hasGDriveURLs := h.containsGDriveURLs(req.Messages)
-
-	// Validate token presence if gdrive:// URLs exist
-	if hasGDriveURLs && gdriveToken == "" {
+	// Validate token presence if gdrive:// URLs exist (kept as a dedicated
+	// check for backward compatibility; other backends surface missing
+	// credentials as a failed Fetch instead).
+	if h.containsManagedURLs(req.Messages, "gdrive") && r.Header.Get("X-Google-Drive-Token") == "" {
 		http.Error(w, "missing Google Drive token for gdrive:// URLs", http.StatusUnauthorized)
 		return
 	}
 
-	// Process messages: download gdrive:// files and convert to base64
-	processedMessages, err := h.processMessages(req.Messages, gdriveToken)
+	// Process messages: download storage:// files and convert to base64
+	processedMessages, err := h.processMessages(r, req.Messages)
 	if err != nil {
 		log.Printf("Failed to process messages: %v", err)
+		var rejected *assetRejectedError
+		if errors.As(err, &rejected) {
+			http.Error(w, rejected.message, rejected.status)
+			return
+		}
 		http.Error(w, fmt.Sprintf("failed to process messages: %v", err), http.StatusBadGateway)
 		return
 	}
 
-	// Convert messages to Vertex AI format
-	contents, err := vertexai.ConvertToVertexAI(processedMessages)
+	// Convert messages (and tools/system instructions) to Vertex AI format.
+	// processMessages rebuilds each message from its role and content only,
+	// so carry the tool-call fields over for the converter.
+	for i := range processedMessages {
+		processedMessages[i].ToolCalls = req.Messages[i].ToolCalls
+		processedMessages[i].ToolCallID = req.Messages[i].ToolCallID
+	}
+	req.Messages = processedMessages
+	contents, genConfig, err := vertexai.ConvertRequest(&req)
 	if err != nil {
 		log.Printf("Failed to convert messages: %v", err)
 		http.Error(w, fmt.Sprintf("failed to convert messages: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	// Handle streaming vs non-streaming
+	// Dispatch through the provider router, which hides which upstream
+	// actually served the request and transparently falls back on failure.
+	includeUsage := req.StreamOptions != nil && req.StreamOptions.IncludeUsage
 	if req.Stream {
-		err = vertexai.StreamResponse(r.Context(), h.vertexClient, req.Model, contents, w)
+		err = h.router.Dispatch(r.Context(), req.Model, contents, genConfig, true, includeUsage, w)
 		if err != nil {
 			log.Printf("Streaming error: %v", err)
 			// Can't send error response as headers already sent
 		}
-	} else {
-		err = vertexai.NonStreamingResponse(r.Context(), h.vertexClient, req.Model, contents, w)
-		if err != nil {
-			log.Printf("Non-streaming error: %v", err)
-			http.Error(w, fmt.Sprintf("failed to generate response: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Response caching only applies to non-streaming completions, and only
+	// when the request isn't using tools: a cached tool-calling response may
+	// depend on external state (the tool's actual execution) the cache can't
+	// account for.
+	if h.completionCache != nil && len(req.Tools) == 0 {
+		h.serveNonStreamingCached(w, r, &req, contents, genConfig)
+		return
+	}
+
+	if err := h.router.Dispatch(r.Context(), req.Model, contents, genConfig, false, includeUsage, w); err != nil {
+		log.Printf("Non-streaming error: %v", err)
+		http.Error(w, fmt.Sprintf("failed to generate response: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// serveNonStreamingCached serves req from h.completionCache when possible,
+// setting X-Cache to "HIT" (exact match), "SEMANTIC" (similarity match) or
+// "MISS". On a miss, it dispatches normally and stores the result for future
+// lookups.
+func (h *Handler) serveNonStreamingCached(w http.ResponseWriter, r *http.Request, req *models.ChatCompletionRequest, contents []*genai.Content, genConfig *genai.GenerateContentConfig) {
+	// apiKey scopes both the exact-match key and the semantic candidate set
+	// to the calling tenant; it's "" when auth is disabled (API_KEYS_FILE
+	// unset), which still keeps all unauthenticated callers on one shared
+	// cache as before.
+	apiKey, _ := auth.APIKeyFromContext(r.Context())
+
+	key, err := cache.Key(req, apiKey)
+	if err != nil {
+		log.Printf("Failed to compute cache key: %v", err)
+		key = ""
+	}
+
+	var queryEmbedding []float32
+	if key != "" && h.completionCache.SemanticEnabled() && h.embeddingModel != "" {
+		if text := lastUserText(req.Messages); text != "" {
+			embResp, err := vertexai.Embed(r.Context(), h.vertexClient, h.embeddingModel, []string{text})
+			if err != nil {
+				log.Printf("Failed to embed query for semantic cache lookup: %v", err)
+			} else if len(embResp.Data) > 0 {
+				queryEmbedding = embResp.Data[0].Embedding
+			}
+		}
+	}
+
+	if key != "" {
+		if cached, hitKind, ok := h.completionCache.Lookup(key, req.Model, apiKey, queryEmbedding); ok {
+			w.Header().Set("X-Cache", hitKind)
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(cached); err != nil {
+				log.Printf("Failed to encode cached response: %v", err)
+			}
+			return
 		}
 	}
+
+	recorder := newResponseRecorder()
+	if err := h.router.Dispatch(r.Context(), req.Model, contents, genConfig, false, false, recorder); err != nil {
+		log.Printf("Non-streaming error: %v", err)
+		http.Error(w, fmt.Sprintf("failed to generate response: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if key != "" {
+		var completion models.ChatCompletionResponse
+		if err := json.Unmarshal(recorder.body.Bytes(), &completion); err == nil {
+			h.completionCache.Store(key, req.Model, apiKey, completion, queryEmbedding)
+		}
+	}
+
+	for k, values := range recorder.header {
+		w.Header()[k] = values
+	}
+	w.Header().Set("X-Cache", "MISS")
+	w.WriteHeader(recorder.statusOr(http.StatusOK))
+	w.Write(recorder.body.Bytes())
+}
+
+// lastUserText returns the text of the last "user" message, for embedding as
+// the semantic cache query. Only plain string content is considered: if the
+// last user message is multimodal, it returns "" since the cache only
+// compares text.
+func lastUserText(messages []models.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != "user" {
+			continue
+		}
+		if text, ok := messages[i].Content.(string); ok {
+			return text
+		}
+		return ""
+	}
+	return ""
+}
+
+// storageFieldByType maps a content part's "type" to the field inside it
+// that carries the content reference (a "scheme://ref" URL or a data URL).
+var storageFieldByType = map[string]string{
+	"image_url": "url",
+	"audio":     "data",
+	"file":      "file_data",
+}
+
+// storageKeyByType maps a content part's "type" to the key under which its
+// nested object (e.g. "image_url") is stored on the part map.
+var storageKeyByType = map[string]string{
+	"image_url": "image_url",
+	"audio":     "audio",
+	"file":      "file",
 }
 
-// processMessages processes messages by downloading gdrive:// files and converting to base64
-func (h *Handler) processMessages(messages []models.Message, gdriveToken string) ([]models.Message, error) {
+// assetRejectedError is returned by processPart when a fetched multimodal
+// asset fails the configured size or MIME allowlist, so HandleChatCompletion
+// can surface it as the matching 4xx rather than a generic 502.
+type assetRejectedError struct {
+	status  int
+	message string
+}
+
+func (e *assetRejectedError) Error() string { return e.message }
+
+// resolvedPart is a cached result of fetching a single storage:// reference,
+// keyed by the reference itself (a given ref always resolves to the same
+// content, so the ref doubles as its own content-identity key).
+type resolvedPart struct {
+	data     []byte
+	mimeType string
+}
+
+// processMessages processes messages by fetching any scheme://-prefixed
+// references (gdrive://, s3://, http(s)://, file://) via the storage
+// registry and converting them to base64 data URLs. References repeated
+// across messages in the same request (a common pattern as a conversation
+// grows and resends its full history) are fetched only once.
+func (h *Handler) processMessages(r *http.Request, messages []models.Message) ([]models.Message, error) {
 	processedMessages := make([]models.Message, len(messages))
+	cache := make(map[string]resolvedPart)
 
 	for i, msg := range messages {
 		// If content is a string, no processing needed
@@ -119,83 +278,9 @@ func (h *Handler) processMessages(messages []models.Message, gdriveToken string)
 				continue
 			}
 
-			// Make a copy to avoid modifying original
-			processedPart := make(map[string]interface{})
-			for k, v := range partMap {
-				processedPart[k] = v
-			}
-
-			partType, _ := partMap["type"].(string)
-
-			switch partType {
-			case "image_url":
-				if imageURL, ok := partMap["image_url"].(map[string]interface{}); ok {
-					if url, ok := imageURL["url"].(string); ok && gdrive.IsGDriveURL(url) {
-						// Download from Google Drive
-						fileID := gdrive.ExtractFileID(url)
-						data, mimeType, err := h.gdriveClient.DownloadFile(fileID, gdriveToken)
-						if err != nil {
-							return nil, fmt.Errorf("failed to download image %s: %w", fileID, err)
-						}
-
-						// Convert to base64 data URL
-						dataURL := vertexai.ToDataURL(data, mimeType)
-
-						// Update the URL
-						imageURLCopy := make(map[string]interface{})
-						for k, v := range imageURL {
-							imageURLCopy[k] = v
-						}
-						imageURLCopy["url"] = dataURL
-						processedPart["image_url"] = imageURLCopy
-					}
-				}
-
-			case "audio":
-				if audio, ok := partMap["audio"].(map[string]interface{}); ok {
-					if dataStr, ok := audio["data"].(string); ok && gdrive.IsGDriveURL(dataStr) {
-						// Download from Google Drive
-						fileID := gdrive.ExtractFileID(dataStr)
-						data, mimeType, err := h.gdriveClient.DownloadFile(fileID, gdriveToken)
-						if err != nil {
-							return nil, fmt.Errorf("failed to download audio %s: %w", fileID, err)
-						}
-
-						// Convert to base64 data URL
-						dataURL := vertexai.ToDataURL(data, mimeType)
-
-						// Update the data field
-						audioCopy := make(map[string]interface{})
-						for k, v := range audio {
-							audioCopy[k] = v
-						}
-						audioCopy["data"] = dataURL
-						processedPart["audio"] = audioCopy
-					}
-				}
-
-			case "file":
-				if file, ok := partMap["file"].(map[string]interface{}); ok {
-					if fileData, ok := file["file_data"].(string); ok && gdrive.IsGDriveURL(fileData) {
-						// Download from Google Drive
-						fileID := gdrive.ExtractFileID(fileData)
-						data, mimeType, err := h.gdriveClient.DownloadFile(fileID, gdriveToken)
-						if err != nil {
-							return nil, fmt.Errorf("failed to download file %s: %w", fileID, err)
-						}
-
-						// Convert to base64 data URL
-						dataURL := vertexai.ToDataURL(data, mimeType)
-
-						// Update the file_data field
-						fileCopy := make(map[string]interface{})
-						for k, v := range file {
-							fileCopy[k] = v
-						}
-						fileCopy["file_data"] = dataURL
-						processedPart["file"] = fileCopy
-					}
-				}
+			processedPart, err := h.processPart(r, partMap, cache)
+			if err != nil {
+				return nil, err
 			}
 
 			processedParts[j] = processedPart
@@ -210,8 +295,84 @@ func (h *Handler) processMessages(messages []models.Message, gdriveToken string)
 	return processedMessages, nil
 }
 
-// containsGDriveURLs checks if any message contains gdrive:// URLs
-func (h *Handler) containsGDriveURLs(messages []models.Message) bool {
+// processPart resolves any storage:// reference carried by a single
+// multimodal content part, returning a copy with the reference replaced by
+// a base64 data URL. cache is consulted and populated so a ref repeated
+// elsewhere in the same request isn't fetched twice.
+func (h *Handler) processPart(r *http.Request, partMap map[string]interface{}, cache map[string]resolvedPart) (map[string]interface{}, error) {
+	// Make a copy to avoid modifying the original
+	processedPart := make(map[string]interface{})
+	for k, v := range partMap {
+		processedPart[k] = v
+	}
+
+	partType, _ := partMap["type"].(string)
+	nestedKey, ok := storageKeyByType[partType]
+	if !ok {
+		return processedPart, nil
+	}
+
+	nested, ok := partMap[nestedKey].(map[string]interface{})
+	if !ok {
+		return processedPart, nil
+	}
+
+	refField := storageFieldByType[partType]
+	ref, ok := nested[refField].(string)
+	if !ok {
+		return processedPart, nil
+	}
+
+	part, ok := cache[ref]
+	if !ok {
+		data, mimeType, resolved, err := resolveStorageRef(r, h.storage, ref, h.maxFileSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s %s: %w", partType, ref, err)
+		}
+		if !resolved {
+			return processedPart, nil
+		}
+
+		if !h.mimeTypeAllowed(mimeType) {
+			return nil, &assetRejectedError{
+				status:  http.StatusUnsupportedMediaType,
+				message: fmt.Sprintf("%s %s has MIME type %q, which is not in the allowed list", partType, ref, mimeType),
+			}
+		}
+
+		part = resolvedPart{data: data, mimeType: mimeType}
+		cache[ref] = part
+	}
+
+	dataURL := vertexai.ToDataURL(part.data, part.mimeType)
+
+	nestedCopy := make(map[string]interface{})
+	for k, v := range nested {
+		nestedCopy[k] = v
+	}
+	nestedCopy[refField] = dataURL
+	processedPart[nestedKey] = nestedCopy
+
+	return processedPart, nil
+}
+
+// mimeTypeAllowed reports whether mimeType passes h.allowedMimeTypes. An
+// empty allowlist allows everything.
+func (h *Handler) mimeTypeAllowed(mimeType string) bool {
+	if len(h.allowedMimeTypes) == 0 {
+		return true
+	}
+	for _, allowed := range h.allowedMimeTypes {
+		if allowed == mimeType {
+			return true
+		}
+	}
+	return false
+}
+
+// containsManagedURLs checks if any message contains a reference handled by
+// the given storage backend scheme
+func (h *Handler) containsManagedURLs(messages []models.Message, scheme string) bool {
 	for _, msg := range messages {
 		// Skip string content
 		if _, ok := msg.Content.(string); ok {
@@ -231,26 +392,23 @@ func (h *Handler) containsGDriveURLs(messages []models.Message) bool {
 			}
 
 			partType, _ := partMap["type"].(string)
+			nestedKey, ok := storageKeyByType[partType]
+			if !ok {
+				continue
+			}
+
+			nested, ok := partMap[nestedKey].(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			ref, ok := nested[storageFieldByType[partType]].(string)
+			if !ok {
+				continue
+			}
 
-			switch partType {
-			case "image_url":
-				if imageURL, ok := partMap["image_url"].(map[string]interface{}); ok {
-					if url, ok := imageURL["url"].(string); ok && gdrive.IsGDriveURL(url) {
-						return true
-					}
-				}
-			case "audio":
-				if audio, ok := partMap["audio"].(map[string]interface{}); ok {
-					if dataStr, ok := audio["data"].(string); ok && gdrive.IsGDriveURL(dataStr) {
-						return true
-					}
-				}
-			case "file":
-				if file, ok := partMap["file"].(map[string]interface{}); ok {
-					if fileData, ok := file["file_data"].(string); ok && gdrive.IsGDriveURL(fileData) {
-						return true
-					}
-				}
+			if _, _, ok := h.storage.Lookup(ref); ok && strings.HasPrefix(ref, scheme+"://") {
+				return true
 			}
 		}
 	}
@@ -258,10 +416,22 @@ func (h *Handler) containsGDriveURLs(messages []models.Message) bool {
 	return false
 }
 
-// HandleHealth handles the /health endpoint
+// HandleHealth handles the /health endpoint, reporting each provider's
+// health alongside the basic liveness check.
 func (h *Handler) HandleHealth(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("OK"))
+	health := map[string]interface{}{
+		"status":    "ok",
+		"providers": h.router.Health(),
+	}
+	if h.completionCache != nil {
+		health["cache"] = h.completionCache.Stats()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(health); err != nil {
+		log.Printf("Failed to encode health response: %v", err)
+		http.Error(w, "error marshaling response", http.StatusInternalServerError)
+	}
 }
 
 // HandleListModels handles the /v1/models endpoint
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// responseRecorder is a minimal http.ResponseWriter that buffers a response
+// in memory instead of writing it, so HandleChatCompletion can inspect (and
+// cache) a provider's response before relaying it to the real client.
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newResponseRecorder() *responseRecorder {
+	return &responseRecorder{header: make(http.Header)}
+}
+
+func (r *responseRecorder) Header() http.Header { return r.header }
+
+func (r *responseRecorder) WriteHeader(status int) { r.status = status }
+
+func (r *responseRecorder) Write(b []byte) (int, error) { return r.body.Write(b) }
+
+// statusOr returns the recorded status code, or fallback if WriteHeader was
+// never called (net/http's default of 200 on the first Write).
+func (r *responseRecorder) statusOr(fallback int) int {
+	if r.status == 0 {
+		return fallback
+	}
+	return r.status
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/owulveryck/agentflowui/backend/storage"
+)
+
+// resolveStorageRef downloads the content referenced by a "scheme://ref"
+// URL through the storage registry. It is shared by every endpoint that
+// accepts Drive-or-other-backend references (chat completions, audio
+// transcriptions, ...) so they all get storage.Backend support for free.
+// If ref isn't recognized by any registered backend, resolved is false and
+// the caller should treat ref as already-resolved content instead (e.g. a
+// data URL or a raw multipart upload).
+//
+// maxFileSize (0 means unlimited) is enforced in two stages so an oversized
+// file is rejected before it's fully buffered into memory: first against
+// backend.Head's reported size (when the backend can report one cheaply),
+// then by capping the actual read with io.LimitReader and checking whether
+// the cap was hit.
+func resolveStorageRef(r *http.Request, registry *storage.Registry, ref string, maxFileSize int64) (data []byte, mimeType string, resolved bool, err error) {
+	backend, backendRef, ok := registry.Lookup(ref)
+	if !ok {
+		return nil, "", false, nil
+	}
+
+	token := storageToken(r, backend.Scheme())
+
+	if maxFileSize > 0 {
+		if _, size, headErr := backend.Head(r.Context(), backendRef, token); headErr == nil && size > maxFileSize {
+			return nil, "", true, &assetRejectedError{
+				status:  http.StatusRequestEntityTooLarge,
+				message: fmt.Sprintf("%s is %d bytes, exceeding the %d byte limit", ref, size, maxFileSize),
+			}
+		}
+	}
+
+	reader, mimeType, err := backend.Fetch(r.Context(), backendRef, token)
+	if err != nil {
+		return nil, "", true, fmt.Errorf("failed to fetch %s: %w", ref, err)
+	}
+	defer reader.Close()
+
+	body := io.Reader(reader)
+	if maxFileSize > 0 {
+		body = io.LimitReader(reader, maxFileSize+1)
+	}
+
+	data, err = io.ReadAll(body)
+	if err != nil {
+		return nil, "", true, fmt.Errorf("failed to read %s: %w", ref, err)
+	}
+
+	if maxFileSize > 0 && int64(len(data)) > maxFileSize {
+		return nil, "", true, &assetRejectedError{
+			status:  http.StatusRequestEntityTooLarge,
+			message: fmt.Sprintf("%s exceeds the %d byte limit", ref, maxFileSize),
+		}
+	}
+
+	return data, mimeType, true, nil
+}
+
+// storageToken returns the auth token to use for a given backend scheme from
+// the scheme-specific X-Storage-Token-<scheme> header. For gdrive, the legacy
+// X-Google-Drive-Token header is checked first and takes precedence when set,
+// so existing clients keep working unchanged.
+func storageToken(r *http.Request, scheme string) string {
+	if scheme == "gdrive" {
+		if token := r.Header.Get("X-Google-Drive-Token"); token != "" {
+			return token
+		}
+	}
+	return r.Header.Get("X-Storage-Token-" + scheme)
+}
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// bucketState is one key's token-bucket state: how many requests and tokens
+// it has left in the current window, when that window resets, and how many
+// tokens it has spent so far this calendar month.
+type bucketState struct {
+	requestsRemaining int
+	tokensRemaining   int
+	resetAt           time.Time
+	monthlyTokensUsed int64
+	monthlyResetAt    time.Time
+}
+
+// Store persists token-bucket state per API key. The default Memory
+// implementation is in-process only; a Redis-backed Store could be plugged
+// in to share limits across replicas.
+type Store interface {
+	// Get returns the current bucket state for key, or ok=false if it has
+	// none yet.
+	Get(key string) (bucketState, bool)
+	// Set stores the bucket state for key.
+	Set(key string, state bucketState)
+}
+
+// MemoryStore is an in-process Store backed by a map.
+type MemoryStore struct {
+	mu     sync.Mutex
+	states map[string]bucketState
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{states: make(map[string]bucketState)}
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(key string) (bucketState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state, ok := s.states[key]
+	return state, ok
+}
+
+// Set implements Store.
+func (s *MemoryStore) Set(key string, state bucketState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.states[key] = state
+}
+
+// Limiter enforces a per-key requests-per-minute and tokens-per-minute
+// budget using a fixed one-minute window per key, backed by a Store.
+type Limiter struct {
+	mu    sync.Mutex
+	store Store
+}
+
+// NewLimiter wraps a Store as a Limiter.
+func NewLimiter(store Store) *Limiter {
+	return &Limiter{store: store}
+}
+
+// Result reports the outcome of an Allow check, mirroring the values
+// surfaced as X-RateLimit-* response headers.
+type Result struct {
+	Allowed           bool
+	RequestsRemaining int
+	TokensRemaining   int
+	ResetAt           time.Time
+}
+
+// Allow consumes one request and estimatedTokens tokens from key's current
+// one-minute window, creating a fresh window if the key is new or its
+// window has expired, and separately checks monthlyQuota against the key's
+// calendar-month running total. rpm/tpm/monthlyQuota of 0 mean unlimited
+// for that dimension.
+func (l *Limiter) Allow(key string, rpm, tpm int, monthlyQuota int64, estimatedTokens int) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	state, ok := l.store.Get(key)
+	if !ok || now.After(state.resetAt) {
+		state.requestsRemaining = rpm
+		state.tokensRemaining = tpm
+		state.resetAt = now.Add(time.Minute)
+	}
+	if !ok || now.After(state.monthlyResetAt) {
+		state.monthlyTokensUsed = 0
+		state.monthlyResetAt = startOfNextMonth(now)
+	}
+
+	allowed := true
+	if rpm > 0 && state.requestsRemaining <= 0 {
+		allowed = false
+	}
+	if tpm > 0 && state.tokensRemaining < estimatedTokens {
+		allowed = false
+	}
+	if monthlyQuota > 0 && state.monthlyTokensUsed+int64(estimatedTokens) > monthlyQuota {
+		allowed = false
+	}
+
+	if allowed {
+		if rpm > 0 {
+			state.requestsRemaining--
+		}
+		if tpm > 0 {
+			state.tokensRemaining -= estimatedTokens
+		}
+		state.monthlyTokensUsed += int64(estimatedTokens)
+	}
+
+	l.store.Set(key, state)
+
+	return Result{
+		Allowed:           allowed,
+		RequestsRemaining: state.requestsRemaining,
+		TokensRemaining:   state.tokensRemaining,
+		ResetAt:           state.resetAt,
+	}
+}
+
+// startOfNextMonth returns midnight UTC on the first day of the month after
+// now, used to reset a key's monthly token quota.
+func startOfNextMonth(now time.Time) time.Time {
+	year, month, _ := now.UTC().Date()
+	return time.Date(year, month+1, 1, 0, 0, 0, 0, time.UTC)
+}
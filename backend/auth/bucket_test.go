@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterAllowsWithinBudget(t *testing.T) {
+	l := NewLimiter(NewMemoryStore())
+
+	res := l.Allow("key1", 2, 100, 0, 10)
+	if !res.Allowed {
+		t.Fatalf("expected first request to be allowed")
+	}
+	if res.RequestsRemaining != 1 {
+		t.Errorf("requests remaining = %d, want 1", res.RequestsRemaining)
+	}
+	if res.TokensRemaining != 90 {
+		t.Errorf("tokens remaining = %d, want 90", res.TokensRemaining)
+	}
+}
+
+func TestLimiterRejectsOverRPM(t *testing.T) {
+	l := NewLimiter(NewMemoryStore())
+
+	l.Allow("key1", 1, 0, 0, 1)
+	res := l.Allow("key1", 1, 0, 0, 1)
+	if res.Allowed {
+		t.Fatalf("expected second request to be rejected once rpm budget is spent")
+	}
+}
+
+func TestLimiterRejectsOverTPM(t *testing.T) {
+	l := NewLimiter(NewMemoryStore())
+
+	res := l.Allow("key1", 0, 100, 0, 150)
+	if res.Allowed {
+		t.Fatalf("expected request estimating more tokens than the tpm budget to be rejected")
+	}
+	if res.TokensRemaining != 100 {
+		t.Errorf("tokens remaining should be untouched on rejection, got %d", res.TokensRemaining)
+	}
+}
+
+func TestLimiterRejectsOverMonthlyQuota(t *testing.T) {
+	l := NewLimiter(NewMemoryStore())
+
+	res := l.Allow("key1", 0, 0, 100, 60)
+	if !res.Allowed {
+		t.Fatalf("expected request within monthly quota to be allowed")
+	}
+	res = l.Allow("key1", 0, 0, 100, 60)
+	if res.Allowed {
+		t.Fatalf("expected request that would exceed monthly quota to be rejected")
+	}
+}
+
+func TestLimiterZeroMeansUnlimited(t *testing.T) {
+	l := NewLimiter(NewMemoryStore())
+
+	res := l.Allow("key1", 0, 0, 0, 1_000_000)
+	if !res.Allowed {
+		t.Fatalf("expected rpm/tpm/monthlyQuota of 0 to mean unlimited")
+	}
+}
+
+func TestLimiterResetsWindowAfterExpiry(t *testing.T) {
+	store := NewMemoryStore()
+	l := NewLimiter(store)
+
+	l.Allow("key1", 1, 0, 0, 1)
+	state, ok := store.Get("key1")
+	if !ok {
+		t.Fatalf("expected state to be stored")
+	}
+	state.resetAt = time.Now().Add(-time.Second)
+	store.Set("key1", state)
+
+	res := l.Allow("key1", 1, 0, 0, 1)
+	if !res.Allowed {
+		t.Fatalf("expected a new request to be allowed once the window has expired")
+	}
+}
+
+func TestStartOfNextMonth(t *testing.T) {
+	now := time.Date(2026, time.July, 29, 15, 30, 0, 0, time.UTC)
+	want := time.Date(2026, time.August, 1, 0, 0, 0, 0, time.UTC)
+	if got := startOfNextMonth(now); !got.Equal(want) {
+		t.Errorf("startOfNextMonth(%v) = %v, want %v", now, got, want)
+	}
+}
@@ -0,0 +1,22 @@
+package auth
+
+import "context"
+
+type contextKey int
+
+const apiKeyContextKey contextKey = 0
+
+// WithAPIKey returns a copy of ctx carrying apiKey, so downstream handlers
+// (e.g. the response cache) can scope per-tenant state without re-parsing
+// the Authorization header.
+func WithAPIKey(ctx context.Context, apiKey string) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey, apiKey)
+}
+
+// APIKeyFromContext returns the API key set by Middleware, if any. ok is
+// false when auth is disabled (API_KEYS_FILE unset) or the request never
+// went through Middleware.
+func APIKeyFromContext(ctx context.Context) (string, bool) {
+	apiKey, ok := ctx.Value(apiKeyContextKey).(string)
+	return apiKey, ok
+}
@@ -0,0 +1,95 @@
+// Package auth provides Bearer-token authentication and per-key rate
+// limiting for the OpenAI-compatible endpoints.
+package auth
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Key is one API key's configuration: which models it may call and the
+// budget enforced against it by a Limiter.
+type Key struct {
+	Key               string   `json:"key"`
+	Models            []string `json:"models,omitempty"` // allowlist; empty means "all models allowed"
+	RPM               int      `json:"rpm,omitempty"`    // requests per minute, 0 means unlimited
+	TPM               int      `json:"tpm,omitempty"`    // tokens per minute, 0 means unlimited
+	MonthlyTokenQuota int64    `json:"monthly_token_quota,omitempty"`
+}
+
+// AllowsModel reports whether this key may call the given model.
+func (k Key) AllowsModel(model string) bool {
+	if len(k.Models) == 0 {
+		return true
+	}
+	for _, allowed := range k.Models {
+		if allowed == model {
+			return true
+		}
+	}
+	return false
+}
+
+// KeyStore holds the set of recognized API keys, reloadable at runtime so
+// operators can rotate keys without restarting the process.
+type KeyStore struct {
+	mu   sync.RWMutex
+	keys map[string]Key
+}
+
+// NewKeyStore returns an empty KeyStore; Lookup fails every key until Load
+// populates it.
+func NewKeyStore() *KeyStore {
+	return &KeyStore{keys: make(map[string]Key)}
+}
+
+// Lookup returns the Key config for an API key, or ok=false if it's not
+// recognized.
+func (s *KeyStore) Lookup(apiKey string) (Key, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[apiKey]
+	return key, ok
+}
+
+// Load replaces the KeyStore's contents by reading path, a JSON-lines file
+// where each line is a Key object. Blank lines and lines starting with "#"
+// are ignored.
+func (s *KeyStore) Load(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open API keys file: %w", err)
+	}
+	defer file.Close()
+
+	keys := make(map[string]Key)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var key Key
+		if err := json.Unmarshal([]byte(line), &key); err != nil {
+			return fmt.Errorf("invalid API key entry %q: %w", line, err)
+		}
+		if key.Key == "" {
+			return fmt.Errorf("API key entry missing \"key\" field: %q", line)
+		}
+		keys[key.Key] = key
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read API keys file: %w", err)
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.mu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// errorResponse mirrors OpenAI's {"error": {...}} shape so rejected requests
+// look like any other OpenAI API error to existing clients.
+type errorResponse struct {
+	Error errorDetail `json:"error"`
+}
+
+type errorDetail struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Code    string `json:"code,omitempty"`
+}
+
+// requestModel is the subset of a chat completion request body Middleware
+// needs to enforce a key's model allowlist.
+type requestModel struct {
+	Model string `json:"model"`
+}
+
+// Middleware wraps next with Bearer-token authentication, per-key model
+// allowlisting, and RPM/TPM rate limiting backed by limiter. Requests
+// missing or failing any check are rejected with an OpenAI-shaped JSON
+// error and never reach next.
+func Middleware(next http.Handler, keys *KeyStore, limiter *Limiter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		apiKey, ok := bearerToken(r)
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "missing or malformed Authorization header", "invalid_request_error", "")
+			return
+		}
+
+		key, ok := keys.Lookup(apiKey)
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "invalid API key", "invalid_request_error", "invalid_api_key")
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("failed to read request body: %v", err), "invalid_request_error", "")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		var reqModel requestModel
+		if err := json.Unmarshal(body, &reqModel); err == nil && reqModel.Model != "" && !key.AllowsModel(reqModel.Model) {
+			writeError(w, http.StatusForbidden, fmt.Sprintf("model %q is not allowed for this API key", reqModel.Model), "invalid_request_error", "model_not_allowed")
+			return
+		}
+
+		result := limiter.Allow(apiKey, key.RPM, key.TPM, key.MonthlyTokenQuota, estimateRequestTokens(body))
+		w.Header().Set("X-RateLimit-Limit-Requests", strconv.Itoa(key.RPM))
+		w.Header().Set("X-RateLimit-Remaining-Requests", strconv.Itoa(result.RequestsRemaining))
+		w.Header().Set("X-RateLimit-Limit-Tokens", strconv.Itoa(key.TPM))
+		w.Header().Set("X-RateLimit-Remaining-Tokens", strconv.Itoa(result.TokensRemaining))
+		w.Header().Set("X-RateLimit-Reset-Requests", result.ResetAt.Format(http.TimeFormat))
+
+		if !result.Allowed {
+			writeError(w, http.StatusTooManyRequests, "rate limit exceeded", "rate_limit_error", "")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithAPIKey(r.Context(), apiKey)))
+	})
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer <token>"
+// header.
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	token = strings.TrimSpace(token)
+	return token, ok && token != ""
+}
+
+// estimateRequestTokens gives a rough pre-flight token estimate for TPM
+// enforcement, using the same ~4 characters-per-token heuristic as the
+// completion-side usage estimate.
+func estimateRequestTokens(body []byte) int {
+	return (len(body) + 3) / 4
+}
+
+// writeError writes an OpenAI-shaped error response.
+func writeError(w http.ResponseWriter, status int, message, errType, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{Error: errorDetail{Message: message, Type: errType, Code: code}})
+}
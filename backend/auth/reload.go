@@ -0,0 +1,24 @@
+package auth
+
+import (
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// WatchReload reloads the KeyStore from path every time the process
+// receives SIGHUP, so operators can rotate keys without restarting. It
+// blocks forever and is meant to be run in its own goroutine.
+func WatchReload(keys *KeyStore, path string) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	for range sighup {
+		if err := keys.Load(path); err != nil {
+			log.Printf("Failed to reload API keys from %s: %v", path, err)
+			continue
+		}
+		log.Printf("Reloaded API keys from %s", path)
+	}
+}
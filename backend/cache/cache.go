@@ -0,0 +1,117 @@
+// Package cache provides exact-match and semantic-similarity response
+// caching for non-streaming chat completions.
+package cache
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/owulveryck/agentflowui/backend/models"
+)
+
+// Entry is a single cached chat completion, keyed by an exact-match hash and
+// carrying an optional embedding for semantic lookup. Model and APIKey are
+// carried alongside the embedding so a semantic scan can never match a
+// candidate generated for a different model or a different tenant, even
+// though the embeddings happen to be similar.
+type Entry struct {
+	Key       string
+	Model     string
+	APIKey    string
+	Response  models.ChatCompletionResponse
+	Embedding []float32
+	ExpiresAt time.Time
+}
+
+// Store persists cache Entries. The default Memory implementation is
+// in-process only; a Redis-backed Store could be plugged in to share the
+// cache across replicas.
+type Store interface {
+	Get(key string) (Entry, bool)
+	Set(entry Entry)
+	// All returns every non-expired entry, for semantic similarity scans.
+	All() []Entry
+	Delete(key string)
+}
+
+// Stats are cumulative hit/miss counters exposed via the /health endpoint.
+type Stats struct {
+	Hits         int64 `json:"hits"`
+	SemanticHits int64 `json:"semantic_hits"`
+	Misses       int64 `json:"misses"`
+}
+
+// Cache combines an exact-match Store with an optional semantic similarity
+// lookup over the same Store's entries.
+type Cache struct {
+	store             Store
+	ttl               time.Duration
+	semanticThreshold float64 // cosine similarity required for a semantic hit; 0 disables semantic lookup
+
+	hits         atomic.Int64
+	semanticHits atomic.Int64
+	misses       atomic.Int64
+}
+
+// New builds a Cache. semanticThreshold of 0 disables semantic lookup,
+// leaving only exact-match caching.
+func New(store Store, ttl time.Duration, semanticThreshold float64) *Cache {
+	return &Cache{store: store, ttl: ttl, semanticThreshold: semanticThreshold}
+}
+
+// SemanticEnabled reports whether this Cache was configured to do semantic
+// similarity lookups, so callers know whether it's worth computing a query
+// embedding at all.
+func (c *Cache) SemanticEnabled() bool {
+	return c.semanticThreshold > 0
+}
+
+// Lookup returns a cached response for key, trying an exact-match hit first
+// and falling back to a semantic similarity match against queryEmbedding
+// (which may be nil to skip the semantic pass). The semantic pass only
+// considers entries whose Model and APIKey match the arguments given here --
+// a merely-similar message from a different model or a different tenant is
+// never an eligible match. hitKind is "HIT", "SEMANTIC", or "" on a miss.
+func (c *Cache) Lookup(key, model, apiKey string, queryEmbedding []float32) (response models.ChatCompletionResponse, hitKind string, ok bool) {
+	if entry, found := c.store.Get(key); found {
+		c.hits.Add(1)
+		return entry.Response, "HIT", true
+	}
+
+	if c.SemanticEnabled() && len(queryEmbedding) > 0 {
+		if entry, found := bestMatch(c.store.All(), queryEmbedding, c.semanticThreshold, model, apiKey); found {
+			c.semanticHits.Add(1)
+			return entry.Response, "SEMANTIC", true
+		}
+	}
+
+	c.misses.Add(1)
+	return models.ChatCompletionResponse{}, "", false
+}
+
+// Store saves a completion under key, with model/apiKey and embedding (if
+// any) attached for future semantic lookups.
+func (c *Cache) Store(key, model, apiKey string, response models.ChatCompletionResponse, embedding []float32) {
+	c.store.Set(Entry{
+		Key:       key,
+		Model:     model,
+		APIKey:    apiKey,
+		Response:  response,
+		Embedding: embedding,
+		ExpiresAt: time.Now().Add(c.ttl),
+	})
+}
+
+// Invalidate removes a single cached entry.
+func (c *Cache) Invalidate(key string) {
+	c.store.Delete(key)
+}
+
+// Stats returns cumulative hit/miss counters.
+func (c *Cache) Stats() Stats {
+	return Stats{
+		Hits:         c.hits.Load(),
+		SemanticHits: c.semanticHits.Load(),
+		Misses:       c.misses.Load(),
+	}
+}
@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"github.com/owulveryck/agentflowui/backend/models"
+)
+
+// Key computes an exact-match cache key from the parts of a request that
+// determine its output -- model, messages and generation params -- plus the
+// caller's API key, so two tenants (or an unauthenticated caller and an
+// authenticated one) can never collide on the same cache entry even when
+// API_KEYS_FILE is unset (apiKey is then always ""). Fields that don't
+// affect the generated content (stream, stream_options) are excluded.
+// Requests with tools are the caller's responsibility to exclude, since a
+// cached tool-calling response may depend on external state the cache can't
+// account for.
+func Key(req *models.ChatCompletionRequest, apiKey string) (string, error) {
+	keyable := struct {
+		Model       string           `json:"model"`
+		APIKey      string           `json:"api_key"`
+		Messages    []models.Message `json:"messages"`
+		Temperature *float32         `json:"temperature,omitempty"`
+		MaxTokens   *int             `json:"max_tokens,omitempty"`
+	}{
+		Model:       req.Model,
+		APIKey:      apiKey,
+		Messages:    req.Messages,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+	}
+
+	data, err := json.Marshal(keyable)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/owulveryck/agentflowui/backend/models"
+)
+
+func TestKeyStableForIdenticalRequests(t *testing.T) {
+	req := &models.ChatCompletionRequest{
+		Model:    "gemini-2.0-flash",
+		Messages: []models.Message{{Role: "user", Content: "hello"}},
+	}
+
+	k1, err := Key(req, "tenant-a")
+	if err != nil {
+		t.Fatalf("Key returned error: %v", err)
+	}
+	k2, err := Key(req, "tenant-a")
+	if err != nil {
+		t.Fatalf("Key returned error: %v", err)
+	}
+	if k1 != k2 {
+		t.Errorf("Key(req, apiKey) should be stable across calls, got %q and %q", k1, k2)
+	}
+}
+
+func TestKeyDiffersByAPIKey(t *testing.T) {
+	req := &models.ChatCompletionRequest{
+		Model:    "gemini-2.0-flash",
+		Messages: []models.Message{{Role: "user", Content: "hello"}},
+	}
+
+	k1, err := Key(req, "tenant-a")
+	if err != nil {
+		t.Fatalf("Key returned error: %v", err)
+	}
+	k2, err := Key(req, "tenant-b")
+	if err != nil {
+		t.Fatalf("Key returned error: %v", err)
+	}
+	if k1 == k2 {
+		t.Errorf("expected keys to differ across API keys, so tenants never collide")
+	}
+}
+
+func TestKeyDiffersByModel(t *testing.T) {
+	messages := []models.Message{{Role: "user", Content: "hello"}}
+
+	k1, err := Key(&models.ChatCompletionRequest{Model: "gemini-2.0-flash", Messages: messages}, "")
+	if err != nil {
+		t.Fatalf("Key returned error: %v", err)
+	}
+	k2, err := Key(&models.ChatCompletionRequest{Model: "gemini-2.0-pro", Messages: messages}, "")
+	if err != nil {
+		t.Fatalf("Key returned error: %v", err)
+	}
+	if k1 == k2 {
+		t.Errorf("expected keys to differ across models")
+	}
+}
+
+func TestKeyDiffersByMessages(t *testing.T) {
+	k1, err := Key(&models.ChatCompletionRequest{
+		Model:    "gemini-2.0-flash",
+		Messages: []models.Message{{Role: "user", Content: "hello"}},
+	}, "")
+	if err != nil {
+		t.Fatalf("Key returned error: %v", err)
+	}
+	k2, err := Key(&models.ChatCompletionRequest{
+		Model:    "gemini-2.0-flash",
+		Messages: []models.Message{{Role: "user", Content: "goodbye"}},
+	}, "")
+	if err != nil {
+		t.Fatalf("Key returned error: %v", err)
+	}
+	if k1 == k2 {
+		t.Errorf("expected keys to differ across message content")
+	}
+}
+
+func TestKeyIgnoresStreamFields(t *testing.T) {
+	base := &models.ChatCompletionRequest{
+		Model:    "gemini-2.0-flash",
+		Messages: []models.Message{{Role: "user", Content: "hello"}},
+	}
+	streamed := &models.ChatCompletionRequest{
+		Model:    "gemini-2.0-flash",
+		Messages: []models.Message{{Role: "user", Content: "hello"}},
+		Stream:   true,
+	}
+
+	k1, err := Key(base, "")
+	if err != nil {
+		t.Fatalf("Key returned error: %v", err)
+	}
+	k2, err := Key(streamed, "")
+	if err != nil {
+		t.Fatalf("Key returned error: %v", err)
+	}
+	if k1 != k2 {
+		t.Errorf("expected stream field to not affect the cache key, since it doesn't affect the generated content")
+	}
+}
@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-process, count-bounded LRU Store. It's the default
+// backend; a Redis-backed Store could be plugged in instead to share the
+// cache across replicas.
+type MemoryStore struct {
+	mu       sync.Mutex
+	maxItems int // 0 means unbounded
+	order    *list.List
+	items    map[string]*list.Element
+}
+
+type itemRecord struct {
+	key   string
+	entry Entry
+}
+
+// NewMemoryStore creates a MemoryStore holding at most maxItems entries,
+// evicting the least recently used entry once that's exceeded.
+func NewMemoryStore(maxItems int) *MemoryStore {
+	return &MemoryStore{
+		maxItems: maxItems,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the entry for key, unless it has expired, in which case it's
+// evicted and treated as a miss.
+func (s *MemoryStore) Get(key string) (Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.items[key]
+	if !ok {
+		return Entry{}, false
+	}
+
+	record := elem.Value.(*itemRecord)
+	if time.Now().After(record.entry.ExpiresAt) {
+		s.removeElement(elem)
+		return Entry{}, false
+	}
+
+	s.order.MoveToFront(elem)
+	return record.entry, true
+}
+
+// Set inserts or replaces entry, evicting the least recently used entry if
+// the store is now over maxItems.
+func (s *MemoryStore) Set(entry Entry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.items[entry.Key]; ok {
+		elem.Value.(*itemRecord).entry = entry
+		s.order.MoveToFront(elem)
+		return
+	}
+
+	elem := s.order.PushFront(&itemRecord{key: entry.Key, entry: entry})
+	s.items[entry.Key] = elem
+
+	for s.maxItems > 0 && s.order.Len() > s.maxItems {
+		s.removeElement(s.order.Back())
+	}
+}
+
+// All returns every non-expired entry, most recently used first.
+func (s *MemoryStore) All() []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	entries := make([]Entry, 0, s.order.Len())
+	for elem := s.order.Front(); elem != nil; elem = elem.Next() {
+		record := elem.Value.(*itemRecord)
+		if now.After(record.entry.ExpiresAt) {
+			continue
+		}
+		entries = append(entries, record.entry)
+	}
+	return entries
+}
+
+// Delete removes key, if present.
+func (s *MemoryStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.items[key]; ok {
+		s.removeElement(elem)
+	}
+}
+
+func (s *MemoryStore) removeElement(elem *list.Element) {
+	s.order.Remove(elem)
+	delete(s.items, elem.Value.(*itemRecord).key)
+}
@@ -0,0 +1,50 @@
+package cache
+
+import "math"
+
+// cosineSimilarity returns the cosine similarity of two equal-length
+// embeddings, or 0 if they're empty or mismatched in length.
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// bestMatch scans entries for the highest cosine similarity to query among
+// entries matching model and apiKey, returning ok=false if none clears
+// threshold. Entries from a different model or a different tenant are never
+// considered, regardless of embedding similarity.
+func bestMatch(entries []Entry, query []float32, threshold float64, model, apiKey string) (Entry, bool) {
+	var best Entry
+	bestScore := -1.0
+
+	for _, entry := range entries {
+		if entry.Model != model || entry.APIKey != apiKey {
+			continue
+		}
+		if len(entry.Embedding) == 0 {
+			continue
+		}
+		if score := cosineSimilarity(entry.Embedding, query); score > bestScore {
+			bestScore = score
+			best = entry
+		}
+	}
+
+	if bestScore < threshold {
+		return Entry{}, false
+	}
+	return best, true
+}
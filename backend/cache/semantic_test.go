@@ -0,0 +1,91 @@
+package cache
+
+import "testing"
+
+func TestCosineSimilarityIdentical(t *testing.T) {
+	v := []float32{1, 2, 3}
+	if sim := cosineSimilarity(v, v); sim < 0.999999 {
+		t.Errorf("cosineSimilarity(v, v) = %v, want ~1", sim)
+	}
+}
+
+func TestCosineSimilarityOrthogonal(t *testing.T) {
+	a := []float32{1, 0}
+	b := []float32{0, 1}
+	if sim := cosineSimilarity(a, b); sim != 0 {
+		t.Errorf("cosineSimilarity(orthogonal) = %v, want 0", sim)
+	}
+}
+
+func TestCosineSimilarityMismatchedLength(t *testing.T) {
+	a := []float32{1, 2, 3}
+	b := []float32{1, 2}
+	if sim := cosineSimilarity(a, b); sim != 0 {
+		t.Errorf("cosineSimilarity(mismatched lengths) = %v, want 0", sim)
+	}
+}
+
+func TestCosineSimilarityEmpty(t *testing.T) {
+	if sim := cosineSimilarity(nil, nil); sim != 0 {
+		t.Errorf("cosineSimilarity(nil, nil) = %v, want 0", sim)
+	}
+}
+
+func TestBestMatchFindsHighestAboveThreshold(t *testing.T) {
+	entries := []Entry{
+		{Key: "low", Model: "m", APIKey: "k", Embedding: []float32{1, 0}},
+		{Key: "high", Model: "m", APIKey: "k", Embedding: []float32{0.99, 0.01}},
+	}
+
+	match, ok := bestMatch(entries, []float32{1, 0}, 0.9, "m", "k")
+	if !ok {
+		t.Fatalf("expected a match above threshold")
+	}
+	if match.Key != "low" {
+		t.Errorf("expected the exact-direction embedding to win, got %q", match.Key)
+	}
+}
+
+func TestBestMatchRejectsBelowThreshold(t *testing.T) {
+	entries := []Entry{
+		{Key: "a", Model: "m", APIKey: "k", Embedding: []float32{0, 1}},
+	}
+
+	_, ok := bestMatch(entries, []float32{1, 0}, 0.5, "m", "k")
+	if ok {
+		t.Errorf("expected no match below threshold")
+	}
+}
+
+func TestBestMatchIgnoresDifferentModel(t *testing.T) {
+	entries := []Entry{
+		{Key: "a", Model: "other-model", APIKey: "k", Embedding: []float32{1, 0}},
+	}
+
+	_, ok := bestMatch(entries, []float32{1, 0}, 0.5, "m", "k")
+	if ok {
+		t.Errorf("expected entries from a different model to never match, even with identical embeddings")
+	}
+}
+
+func TestBestMatchIgnoresDifferentTenant(t *testing.T) {
+	entries := []Entry{
+		{Key: "a", Model: "m", APIKey: "other-tenant", Embedding: []float32{1, 0}},
+	}
+
+	_, ok := bestMatch(entries, []float32{1, 0}, 0.5, "m", "k")
+	if ok {
+		t.Errorf("expected entries from a different tenant to never match, even with identical embeddings")
+	}
+}
+
+func TestBestMatchIgnoresEntriesWithoutEmbedding(t *testing.T) {
+	entries := []Entry{
+		{Key: "a", Model: "m", APIKey: "k", Embedding: nil},
+	}
+
+	_, ok := bestMatch(entries, []float32{1, 0}, 0, "m", "k")
+	if ok {
+		t.Errorf("expected entries without an embedding to be skipped")
+	}
+}
@@ -4,27 +4,75 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/owulveryck/agentflowui/backend/gdrive"
+	"github.com/owulveryck/agentflowui/backend/providers"
 )
 
 // Config holds the application configuration
 type Config struct {
-	GCPProject     string
-	GCPLocation    string
-	Port           int
-	AllowedOrigins []string
-	MaxFileSize    int64    // in bytes
-	GeminiModels   []string // List of available Gemini models
+	GCPProject              string
+	GCPLocation             string
+	Port                    int
+	AllowedOrigins          []string
+	MaxFileSize             int64             // in bytes
+	AllowedContentMimeTypes []string          // allowed MIME types for fetched multimodal parts; empty means "allow all"
+	GeminiModels            []string          // List of available Gemini models
+	GDriveExportMap         map[string]string // Export MIME type per Google Workspace MIME type
+
+	// Storage backend configuration (see backend/storage)
+	S3Region            string   // AWS region for the s3:// backend
+	S3AccessKeyID       string   // Static server-side credentials for s3://, optional
+	S3SecretAccessKey   string   // Static server-side credentials for s3://, optional
+	AllowedStorageHosts []string // Host allow-list for the http(s):// backend
+	FileStorageRoot     string   // Root directory for the file:// backend; empty disables it
+
+	// Google Drive content cache (see gdrive.Cache)
+	GDriveCacheBackend  string // "memory" or "disk"
+	GDriveCacheMaxBytes int64  // Cache size budget, in bytes
+	GDriveCacheDir      string // Root directory for the "disk" backend
+
+	// Model routing and failover (see backend/providers)
+	ModelRoutes []providers.Route // Fallback chains parsed from MODEL_ROUTES
+
+	// Auth / rate limiting (see backend/auth)
+	APIKeysFile string // Path to a JSON-lines file of auth.Key entries; empty disables auth
+
+	// Non-streaming response cache (see backend/cache)
+	CacheEnabled           bool          // Whether to cache non-streaming completions
+	CacheMaxItems          int           // Max entries held by the in-memory LRU store
+	CacheTTL               time.Duration // How long a cached completion stays valid
+	CacheSemanticThreshold float64       // Cosine similarity required for a semantic hit; 0 disables semantic lookup
+	CacheEmbeddingModel    string        // Embedding model used for semantic cache lookups
 }
 
 // Load loads configuration from environment variables
 func Load() *Config {
 	return &Config{
-		GCPProject:     getEnv("GCP_PROJECT", ""),
-		GCPLocation:    getEnv("GCP_LOCATION", "us-central1"),
-		Port:           getEnvInt("PORT", 8080),
-		AllowedOrigins: getAllowedOrigins(),
-		MaxFileSize:    50 * 1024 * 1024, // 50MB default
-		GeminiModels:   getGeminiModels(),
+		GCPProject:              getEnv("GCP_PROJECT", ""),
+		GCPLocation:             getEnv("GCP_LOCATION", "us-central1"),
+		Port:                    getEnvInt("PORT", 8080),
+		AllowedOrigins:          getAllowedOrigins(),
+		MaxFileSize:             50 * 1024 * 1024, // 50MB default
+		AllowedContentMimeTypes: getCommaList("ALLOWED_CONTENT_MIME_TYPES", ""),
+		GeminiModels:            getGeminiModels(),
+		GDriveExportMap:         getGDriveExportMap(),
+		S3Region:                getEnv("S3_REGION", "us-east-1"),
+		S3AccessKeyID:           getEnv("S3_ACCESS_KEY_ID", ""),
+		S3SecretAccessKey:       getEnv("S3_SECRET_ACCESS_KEY", ""),
+		AllowedStorageHosts:     getCommaList("ALLOWED_STORAGE_HOSTS", ""),
+		FileStorageRoot:         getEnv("FILE_STORAGE_ROOT", ""),
+		GDriveCacheBackend:      getEnv("GDRIVE_CACHE_BACKEND", "memory"),
+		GDriveCacheMaxBytes:     getEnvInt64("GDRIVE_CACHE_MAX_BYTES", 500*1024*1024), // 500MB default
+		GDriveCacheDir:          getEnv("GDRIVE_CACHE_DIR", defaultGDriveCacheDir()),
+		ModelRoutes:             getModelRoutes(),
+		APIKeysFile:             getEnv("API_KEYS_FILE", ""),
+		CacheEnabled:            getEnvBool("CACHE_ENABLED", false),
+		CacheMaxItems:           getEnvInt("CACHE_MAX_ITEMS", 1000),
+		CacheTTL:                getEnvDuration("CACHE_TTL", time.Hour),
+		CacheSemanticThreshold:  getEnvFloat64("CACHE_SEMANTIC_THRESHOLD", 0),
+		CacheEmbeddingModel:     getEnv("CACHE_EMBEDDING_MODEL", "text-embedding-004"),
 	}
 }
 
@@ -52,6 +100,82 @@ func getEnvInt(key string, defaultValue int) int {
 	return value
 }
 
+// getEnvInt64 gets an int64 environment variable or returns a default value
+func getEnvInt64(key string, defaultValue int64) int64 {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseInt(valueStr, 10, 64)
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+// getEnvBool gets a boolean environment variable or returns a default value
+func getEnvBool(key string, defaultValue bool) bool {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+// getEnvFloat64 gets a float64 environment variable or returns a default value
+func getEnvFloat64(key string, defaultValue float64) float64 {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.ParseFloat(valueStr, 64)
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+// getEnvDuration gets a duration environment variable (e.g. "90m") or returns
+// a default value
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	valueStr := os.Getenv(key)
+	if valueStr == "" {
+		return defaultValue
+	}
+
+	value, err := time.ParseDuration(valueStr)
+	if err != nil {
+		return defaultValue
+	}
+
+	return value
+}
+
+// defaultGDriveCacheDir returns $XDG_CACHE_HOME/agentflowui, falling back to
+// os.UserCacheDir() when XDG_CACHE_HOME is unset.
+func defaultGDriveCacheDir() string {
+	if xdgCacheHome := os.Getenv("XDG_CACHE_HOME"); xdgCacheHome != "" {
+		return xdgCacheHome + "/agentflowui"
+	}
+
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return ".cache/agentflowui"
+	}
+
+	return cacheDir + "/agentflowui"
+}
+
 // getAllowedOrigins gets the list of allowed CORS origins
 func getAllowedOrigins() []string {
 	originsStr := getEnv("ALLOWED_ORIGINS", "http://localhost:8000,http://localhost:3000,http://localhost:8080")
@@ -77,3 +201,109 @@ func getGeminiModels() []string {
 
 	return models
 }
+
+// getCommaList gets a comma-separated environment variable as a trimmed
+// string slice. An unset variable falls back to defaultValue (itself
+// comma-separated); an empty defaultValue yields an empty slice.
+func getCommaList(key, defaultValue string) []string {
+	valueStr := getEnv(key, defaultValue)
+	if valueStr == "" {
+		return []string{}
+	}
+
+	values := strings.Split(valueStr, ",")
+	for i, v := range values {
+		values[i] = strings.TrimSpace(v)
+	}
+
+	return values
+}
+
+// getGDriveExportMap gets the Google Workspace export MIME map from the
+// GDRIVE_EXPORT_MAP environment variable. The expected format is a
+// comma-separated list of "sourceMimeType=exportMimeType" pairs, e.g.
+// "application/vnd.google-apps.document=application/pdf". Falls back to
+// gdrive.DefaultExportMap() when unset.
+func getGDriveExportMap() map[string]string {
+	mapStr := os.Getenv("GDRIVE_EXPORT_MAP")
+	if mapStr == "" {
+		return gdrive.DefaultExportMap()
+	}
+
+	exportMap := make(map[string]string)
+	for _, pair := range strings.Split(mapStr, ",") {
+		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		exportMap[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+
+	return exportMap
+}
+
+// routeStrategies maps the MODEL_ROUTES "@strategy" suffix to a
+// providers.Strategy. "weighted" isn't listed here because Router doesn't
+// implement it yet.
+var routeStrategies = map[string]providers.Strategy{
+	string(providers.StrategyPriority):     providers.StrategyPriority,
+	string(providers.StrategyRoundRobin):   providers.StrategyRoundRobin,
+	string(providers.StrategyLeastLatency): providers.StrategyLeastLatency,
+}
+
+// getModelRoutes parses the MODEL_ROUTES environment variable into a set of
+// providers.Router fallback chains. The expected format is a
+// semicolon-separated list of "alias[@strategy]:provider/model,provider/model"
+// entries, e.g.
+// "gpt-fallback@least-latency:vertex/gemini-2.0-flash,anthropic/claude-3-5-sonnet".
+// strategy is one of "priority" (the default), "round-robin" or
+// "least-latency"; an absent or unrecognized strategy falls back to
+// priority. An unset or malformed variable yields no routes, which leaves
+// the router falling back to its single-provider default behavior.
+func getModelRoutes() []providers.Route {
+	routesStr := os.Getenv("MODEL_ROUTES")
+	if routesStr == "" {
+		return nil
+	}
+
+	var routes []providers.Route
+	for _, routeStr := range strings.Split(routesStr, ";") {
+		routeStr = strings.TrimSpace(routeStr)
+		if routeStr == "" {
+			continue
+		}
+
+		aliasAndStrategy, chain, ok := strings.Cut(routeStr, ":")
+		if !ok {
+			continue
+		}
+
+		alias, strategyName, _ := strings.Cut(aliasAndStrategy, "@")
+		strategy, ok := routeStrategies[strings.TrimSpace(strategyName)]
+		if !ok {
+			strategy = providers.StrategyPriority
+		}
+
+		var candidates []providers.Candidate
+		for _, entry := range strings.Split(chain, ",") {
+			providerName, model, ok := strings.Cut(strings.TrimSpace(entry), "/")
+			if !ok {
+				continue
+			}
+			candidates = append(candidates, providers.Candidate{
+				Provider: strings.TrimSpace(providerName),
+				Model:    strings.TrimSpace(model),
+			})
+		}
+
+		if len(candidates) > 0 {
+			routes = append(routes, providers.Route{
+				Alias:      strings.TrimSpace(alias),
+				Candidates: candidates,
+				Strategy:   strategy,
+			})
+		}
+	}
+
+	return routes
+}
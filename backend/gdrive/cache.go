@@ -0,0 +1,180 @@
+package gdrive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// CacheEntry holds cached content and revalidation metadata for a single
+// Drive file.
+type CacheEntry struct {
+	Data        []byte
+	MimeType    string
+	MD5Checksum string
+	ETag        string
+	Size        int64
+	FetchedAt   time.Time
+}
+
+// CacheStore is the storage interface behind Cache. MemoryCacheStore and
+// DiskCacheStore are the two built-in implementations, selected via
+// GDRIVE_CACHE_BACKEND.
+type CacheStore interface {
+	Get(fileID string) (*CacheEntry, bool)
+	Set(fileID string, entry *CacheEntry)
+}
+
+// Cache sits in front of Client.DownloadFile and Client.Open. It revalidates
+// a cached entry against Drive's md5Checksum before serving it. DownloadFile
+// coalesces concurrent calls for the same fileID and access token onto a
+// single download; Open does not coalesce concurrent calls.
+type Cache struct {
+	client *Client
+	store  CacheStore
+	group  singleflight.Group
+}
+
+// NewCache wraps client with a revalidating cache backed by store.
+func NewCache(client *Client, store CacheStore) *Cache {
+	return &Cache{client: client, store: store}
+}
+
+// Open serves fileID the same way DownloadFile does -- revalidating against
+// Drive's current md5Checksum and serving the cache on a match -- but
+// streams rather than buffers. On a cache hit, the cached bytes are served
+// through a bytes.Reader; on a miss, content streams directly from Drive via
+// Client.Open while being copied into a buffer that's saved to the cache
+// once the caller has read the file in full (see cachingReadCloser). Unlike
+// DownloadFile, concurrent Opens for the same fileID are not coalesced: a
+// stream can't be shared between readers at different positions the way a
+// []byte can.
+func (c *Cache) Open(ctx context.Context, fileID, accessToken string) (*MediaHandle, error) {
+	srv, err := c.client.driveService(ctx, accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build drive service: %w", err)
+	}
+
+	meta, err := c.client.getMetadata(srv, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revalidate cache for %s: %w", fileID, err)
+	}
+
+	if cached, ok := c.store.Get(fileID); ok && meta.md5Checksum != "" && cached.MD5Checksum == meta.md5Checksum {
+		return &MediaHandle{
+			ReadCloser: io.NopCloser(bytes.NewReader(cached.Data)),
+			MimeType:   cached.MimeType,
+		}, nil
+	}
+
+	handle, err := c.client.Open(ctx, fileID, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MediaHandle{
+		ReadCloser: newCachingReadCloser(handle, fileID, meta, c.store),
+		MimeType:   handle.MimeType,
+	}, nil
+}
+
+// cachingReadCloser tees a streamed Drive read into a buffer, saving it to
+// the cache store once the caller has consumed the stream in full. A short
+// read (the caller closes before reaching EOF) is never cached, since a
+// partial entry would be served as if it were the whole file on a future
+// cache hit.
+type cachingReadCloser struct {
+	io.Reader
+	underlying io.Closer
+	buf        *bytes.Buffer
+
+	fileID   string
+	mimeType string
+	meta     *fileMetadata
+	store    CacheStore
+}
+
+func newCachingReadCloser(handle *MediaHandle, fileID string, meta *fileMetadata, store CacheStore) *cachingReadCloser {
+	buf := &bytes.Buffer{}
+	return &cachingReadCloser{
+		Reader:     io.TeeReader(handle, buf),
+		underlying: handle,
+		buf:        buf,
+		fileID:     fileID,
+		mimeType:   handle.MimeType,
+		meta:       meta,
+		store:      store,
+	}
+}
+
+func (r *cachingReadCloser) Close() error {
+	err := r.underlying.Close()
+	if int64(r.buf.Len()) == r.meta.size {
+		r.store.Set(r.fileID, &CacheEntry{
+			Data:        r.buf.Bytes(),
+			MimeType:    r.mimeType,
+			MD5Checksum: r.meta.md5Checksum,
+			Size:        r.meta.size,
+			FetchedAt:   time.Now(),
+		})
+	}
+	return err
+}
+
+// DownloadFile serves fileID from cache when Drive reports an unchanged
+// md5Checksum, otherwise downloads a fresh copy and caches it. Concurrent
+// calls for the same fileID share a single in-flight download, but only
+// when they carry the same accessToken -- the group key includes the token
+// so a caller can never be handed content fetched on another caller's
+// credentials.
+func (c *Cache) DownloadFile(fileID, accessToken string) ([]byte, string, error) {
+	v, err, _ := c.group.Do(fileID+"\x00"+accessToken, func() (interface{}, error) {
+		return c.revalidate(fileID, accessToken)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	entry := v.(*CacheEntry)
+	return entry.Data, entry.MimeType, nil
+}
+
+// revalidate checks the cached entry's md5Checksum against Drive's current
+// metadata, serving the cache on a match and refetching otherwise.
+func (c *Cache) revalidate(fileID, accessToken string) (*CacheEntry, error) {
+	cached, hasCached := c.store.Get(fileID)
+
+	srv, err := c.client.driveService(context.Background(), accessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build drive service: %w", err)
+	}
+
+	meta, err := c.client.getMetadata(srv, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revalidate cache for %s: %w", fileID, err)
+	}
+
+	if hasCached && meta.md5Checksum != "" && cached.MD5Checksum == meta.md5Checksum {
+		return cached, nil
+	}
+
+	data, mimeType, err := c.client.DownloadFile(fileID, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &CacheEntry{
+		Data:        data,
+		MimeType:    mimeType,
+		MD5Checksum: meta.md5Checksum,
+		Size:        meta.size,
+		FetchedAt:   time.Now(),
+	}
+	c.store.Set(fileID, entry)
+
+	return entry, nil
+}
@@ -0,0 +1,162 @@
+package gdrive
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DiskCacheStore is a disk-backed LRU CacheStore rooted at a directory,
+// typically $XDG_CACHE_HOME/agentflowui, evicting least-recently-used
+// entries by file modification time once the store exceeds its byte
+// budget.
+type DiskCacheStore struct {
+	mu       sync.Mutex
+	dir      string
+	maxBytes int64
+}
+
+// NewDiskCacheStore creates a disk-backed cache rooted at dir, bounded by
+// maxBytes of cached file content.
+func NewDiskCacheStore(dir string, maxBytes int64) (*DiskCacheStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &DiskCacheStore{dir: dir, maxBytes: maxBytes}, nil
+}
+
+// diskCacheMeta is the on-disk JSON sidecar recording a CacheEntry's
+// revalidation metadata alongside its cached ".data" file.
+type diskCacheMeta struct {
+	MimeType    string    `json:"mimeType"`
+	MD5Checksum string    `json:"md5Checksum"`
+	ETag        string    `json:"etag"`
+	Size        int64     `json:"size"`
+	FetchedAt   time.Time `json:"fetchedAt"`
+}
+
+// Get returns the cached entry for fileID, if present, and refreshes its
+// modification time so the disk LRU treats it as recently used.
+func (s *DiskCacheStore) Get(fileID string) (*CacheEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dataPath, metaPath := s.paths(fileID)
+
+	metaBytes, err := os.ReadFile(metaPath)
+	if err != nil {
+		return nil, false
+	}
+	var meta diskCacheMeta
+	if err := json.Unmarshal(metaBytes, &meta); err != nil {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(dataPath)
+	if err != nil {
+		return nil, false
+	}
+
+	now := time.Now()
+	os.Chtimes(dataPath, now, now)
+	os.Chtimes(metaPath, now, now)
+
+	return &CacheEntry{
+		Data:        data,
+		MimeType:    meta.MimeType,
+		MD5Checksum: meta.MD5Checksum,
+		ETag:        meta.ETag,
+		Size:        meta.Size,
+		FetchedAt:   meta.FetchedAt,
+	}, true
+}
+
+// Set writes entry for fileID to disk and evicts older entries until the
+// store is back under its byte budget.
+func (s *DiskCacheStore) Set(fileID string, entry *CacheEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dataPath, metaPath := s.paths(fileID)
+
+	if err := os.WriteFile(dataPath, entry.Data, 0o644); err != nil {
+		return
+	}
+
+	meta := diskCacheMeta{
+		MimeType:    entry.MimeType,
+		MD5Checksum: entry.MD5Checksum,
+		ETag:        entry.ETag,
+		Size:        entry.Size,
+		FetchedAt:   entry.FetchedAt,
+	}
+	metaBytes, err := json.Marshal(meta)
+	if err != nil {
+		return
+	}
+	if err := os.WriteFile(metaPath, metaBytes, 0o644); err != nil {
+		return
+	}
+
+	s.evictIfNeeded()
+}
+
+// paths returns the data and metadata file paths for fileID, named by its
+// hash so arbitrary file IDs are always safe path components.
+func (s *DiskCacheStore) paths(fileID string) (dataPath, metaPath string) {
+	sum := sha256.Sum256([]byte(fileID))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(s.dir, name+".data"), filepath.Join(s.dir, name+".json")
+}
+
+// evictIfNeeded removes least-recently-used cache entries (by file mtime)
+// until the store's total size is back under maxBytes.
+func (s *DiskCacheStore) evictIfNeeded() {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+
+	type fileInfo struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	var files []fileInfo
+	var total int64
+
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".data" {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, fileInfo{path: filepath.Join(s.dir, e.Name()), size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+	}
+
+	if total <= s.maxBytes {
+		return
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+
+	for _, f := range files {
+		if total <= s.maxBytes {
+			break
+		}
+		os.Remove(f.path)
+		os.Remove(strings.TrimSuffix(f.path, ".data") + ".json")
+		total -= f.size
+	}
+}
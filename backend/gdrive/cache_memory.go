@@ -0,0 +1,71 @@
+package gdrive
+
+import (
+	"container/list"
+	"sync"
+)
+
+// MemoryCacheStore is an in-memory LRU CacheStore bounded by a total byte
+// budget across all cached file content.
+type MemoryCacheStore struct {
+	mu        sync.Mutex
+	maxBytes  int64
+	usedBytes int64
+	ll        *list.List
+	items     map[string]*list.Element
+}
+
+type memoryCacheItem struct {
+	fileID string
+	entry  *CacheEntry
+}
+
+// NewMemoryCacheStore creates an in-memory LRU cache bounded by maxBytes of
+// cached file content.
+func NewMemoryCacheStore(maxBytes int64) *MemoryCacheStore {
+	return &MemoryCacheStore{
+		maxBytes: maxBytes,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the cached entry for fileID, if present, and marks it
+// most-recently-used.
+func (s *MemoryCacheStore) Get(fileID string) (*CacheEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.items[fileID]
+	if !ok {
+		return nil, false
+	}
+	s.ll.MoveToFront(el)
+
+	return el.Value.(*memoryCacheItem).entry, true
+}
+
+// Set stores entry for fileID, evicting least-recently-used entries until
+// the store is back under its byte budget.
+func (s *MemoryCacheStore) Set(fileID string, entry *CacheEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.items[fileID]; ok {
+		s.usedBytes -= int64(len(el.Value.(*memoryCacheItem).entry.Data))
+		el.Value = &memoryCacheItem{fileID: fileID, entry: entry}
+		s.ll.MoveToFront(el)
+	} else {
+		el := s.ll.PushFront(&memoryCacheItem{fileID: fileID, entry: entry})
+		s.items[fileID] = el
+	}
+	s.usedBytes += int64(len(entry.Data))
+
+	for s.usedBytes > s.maxBytes && s.ll.Len() > 0 {
+		oldest := s.ll.Back()
+		item := oldest.Value.(*memoryCacheItem)
+		s.usedBytes -= int64(len(item.entry.Data))
+		delete(s.items, item.fileID)
+		s.ll.Remove(oldest)
+	}
+}
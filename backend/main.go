@@ -10,8 +10,12 @@ import (
 	"time"
 
 	"github.com/owulveryck/agentflowui/backend/api"
+	"github.com/owulveryck/agentflowui/backend/auth"
+	"github.com/owulveryck/agentflowui/backend/cache"
 	"github.com/owulveryck/agentflowui/backend/config"
 	"github.com/owulveryck/agentflowui/backend/gdrive"
+	"github.com/owulveryck/agentflowui/backend/providers"
+	"github.com/owulveryck/agentflowui/backend/storage"
 	"github.com/rs/cors"
 	"google.golang.org/genai"
 )
@@ -46,18 +50,74 @@ func main() {
 	log.Printf("Vertex AI client initialized successfully")
 
 	// Initialize Google Drive client
-	gdriveClient := gdrive.NewClient(&http.Client{
+	gdriveClient := gdrive.NewClientWithExportMap(&http.Client{
 		Timeout: 60 * time.Second, // Generous timeout for large file downloads
-	})
+	}, cfg.GDriveExportMap)
+
+	// Wrap the Drive client in a revalidating content cache so multi-turn
+	// chats don't re-download the same gdrive:// file on every request
+	gdriveCacheStore, err := newGDriveCacheStore(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize Google Drive cache: %v", err)
+	}
+	gdriveCache := gdrive.NewCache(gdriveClient, gdriveCacheStore)
+
+	// Initialize the storage backend registry so content URLs aren't
+	// limited to gdrive://
+	storageRegistry := storage.NewRegistry()
+	storageRegistry.Register(storage.NewGDriveBackendWithCache(gdriveClient, gdriveCache))
+	storageRegistry.Register(storage.NewS3Backend(cfg.S3Region, cfg.S3AccessKeyID, cfg.S3SecretAccessKey))
+	httpStorageClient := &http.Client{Timeout: 60 * time.Second}
+	storageRegistry.Register(storage.NewHTTPBackend("http", httpStorageClient, cfg.AllowedStorageHosts))
+	storageRegistry.Register(storage.NewHTTPBackend("https", httpStorageClient, cfg.AllowedStorageHosts))
+	if cfg.FileStorageRoot != "" {
+		storageRegistry.Register(storage.NewFileBackend(cfg.FileStorageRoot))
+	}
+
+	// Initialize the multi-provider router. Vertex AI is always registered;
+	// additional Provider implementations (Anthropic, OpenAI, ...) would be
+	// registered here too. cfg.ModelRoutes declares fallback chains for
+	// model aliases (MODEL_ROUTES); models with no declared route fall back
+	// to trying each registered provider in registration order.
+	router := providers.NewRouter([]providers.Provider{
+		providers.NewVertexProvider(vertexClient),
+	}, cfg.ModelRoutes)
 
 	// Initialize handler
-	handler := api.NewHandler(vertexClient, gdriveClient, cfg.GeminiModels)
+	handler := api.NewHandler(vertexClient, router, storageRegistry, cfg.GeminiModels, cfg.MaxFileSize, cfg.AllowedContentMimeTypes)
+
+	// Enable non-streaming response caching (exact match, plus semantic
+	// similarity when CACHE_SEMANTIC_THRESHOLD > 0) if configured.
+	if cfg.CacheEnabled {
+		completionCache := cache.New(cache.NewMemoryStore(cfg.CacheMaxItems), cfg.CacheTTL, cfg.CacheSemanticThreshold)
+		handler = handler.WithCompletionCache(completionCache, cfg.CacheEmbeddingModel)
+		log.Printf("Response cache enabled (max %d items, TTL %s, semantic threshold %.2f)", cfg.CacheMaxItems, cfg.CacheTTL, cfg.CacheSemanticThreshold)
+	}
+
+	// If API_KEYS_FILE is set, wrap chat completions with Bearer-token auth,
+	// per-key model allowlisting and RPM/TPM rate limiting. Keys are
+	// reloaded on SIGHUP so operators can rotate them without restarting.
+	var chatCompletionsHandler http.Handler = http.HandlerFunc(handler.HandleChatCompletion)
+	if cfg.APIKeysFile != "" {
+		keyStore := auth.NewKeyStore()
+		if err := keyStore.Load(cfg.APIKeysFile); err != nil {
+			log.Fatalf("Failed to load API keys: %v", err)
+		}
+		go auth.WatchReload(keyStore, cfg.APIKeysFile)
+
+		limiter := auth.NewLimiter(auth.NewMemoryStore())
+		chatCompletionsHandler = auth.Middleware(chatCompletionsHandler, keyStore, limiter)
+		log.Printf("API key authentication enabled from %s", cfg.APIKeysFile)
+	}
 
 	// Setup routes
 	mux := http.NewServeMux()
-	mux.HandleFunc("/v1/chat/completions", handler.HandleChatCompletion)
+	mux.Handle("/v1/chat/completions", chatCompletionsHandler)
 	mux.HandleFunc("/v1/models", handler.HandleListModels)
 	mux.HandleFunc("/v1/models/", handler.HandleGetModel)
+	mux.HandleFunc("/v1/embeddings", handler.HandleEmbeddings)
+	mux.HandleFunc("/v1/audio/transcriptions", handler.HandleTranscriptions)
+	mux.HandleFunc("/v1/audio/speech", handler.HandleSpeech)
 	mux.HandleFunc("/health", handler.HandleHealth)
 
 	// Get the parent directory (project root) to serve static files
@@ -88,7 +148,7 @@ func main() {
 	corsHandler := cors.New(cors.Options{
 		AllowedOrigins:   cfg.AllowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "OPTIONS"},
-		AllowedHeaders:   []string{"Content-Type", "X-Google-Drive-Token"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization", "X-Google-Drive-Token", "X-Storage-Token-s3", "X-Storage-Token-http", "X-Storage-Token-https"},
 		AllowCredentials: true,
 		Debug:            false,
 	}).Handler(loggedMux)
@@ -107,6 +167,19 @@ func main() {
 	}
 }
 
+// newGDriveCacheStore builds the gdrive.CacheStore selected by
+// cfg.GDriveCacheBackend ("memory" or "disk")
+func newGDriveCacheStore(cfg *config.Config) (gdrive.CacheStore, error) {
+	switch cfg.GDriveCacheBackend {
+	case "disk":
+		return gdrive.NewDiskCacheStore(cfg.GDriveCacheDir, cfg.GDriveCacheMaxBytes)
+	case "memory", "":
+		return gdrive.NewMemoryCacheStore(cfg.GDriveCacheMaxBytes), nil
+	default:
+		return nil, fmt.Errorf("unknown GDRIVE_CACHE_BACKEND %q", cfg.GDriveCacheBackend)
+	}
+}
+
 // loggingMiddleware logs HTTP requests
 func loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,14 @@
+package models
+
+// TranscriptionResponse represents the response for /v1/audio/transcriptions
+type TranscriptionResponse struct {
+	Text string `json:"text"`
+}
+
+// SpeechRequest represents a request to /v1/audio/speech
+type SpeechRequest struct {
+	Model          string `json:"model"`
+	Input          string `json:"input"`
+	Voice          string `json:"voice"`
+	ResponseFormat string `json:"response_format,omitempty"` // "wav" or "pcm"; defaults to "wav"
+}
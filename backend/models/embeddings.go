@@ -0,0 +1,29 @@
+package models
+
+// EmbeddingsRequest represents an OpenAI-compatible embeddings request
+type EmbeddingsRequest struct {
+	Model string      `json:"model"`
+	Input interface{} `json:"input"` // string or []string
+}
+
+// EmbeddingData represents a single embedding result
+type EmbeddingData struct {
+	Embedding []float32 `json:"embedding"`
+	Index     int       `json:"index"`
+	Object    string    `json:"object"` // "embedding"
+}
+
+// EmbeddingsResponse represents the response for an embeddings request
+type EmbeddingsResponse struct {
+	Object string          `json:"object"` // "list"
+	Data   []EmbeddingData `json:"data"`
+	Model  string          `json:"model"`
+	Usage  Usage           `json:"usage"`
+}
+
+// Usage represents token usage accounting for a request
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens,omitempty"`
+	TotalTokens      int `json:"total_tokens"`
+}
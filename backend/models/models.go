@@ -2,17 +2,27 @@ package models
 
 // ChatCompletionRequest represents an OpenAI-compatible chat completion request
 type ChatCompletionRequest struct {
-	Model       string    `json:"model"`
-	Messages    []Message `json:"messages"`
-	Stream      bool      `json:"stream"`
-	Temperature *float32  `json:"temperature,omitempty"`
-	MaxTokens   *int      `json:"max_tokens,omitempty"`
+	Model         string         `json:"model"`
+	Messages      []Message      `json:"messages"`
+	Stream        bool           `json:"stream"`
+	Temperature   *float32       `json:"temperature,omitempty"`
+	MaxTokens     *int           `json:"max_tokens,omitempty"`
+	Tools         []Tool         `json:"tools,omitempty"`
+	ToolChoice    interface{}    `json:"tool_choice,omitempty"` // "auto", "none", "required", or {"type":"function","function":{"name":...}}
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+}
+
+// StreamOptions controls optional streaming behavior
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage,omitempty"` // emit a final usage-only SSE chunk before [DONE]
 }
 
 // Message represents a chat message
 type Message struct {
-	Role    string      `json:"role"`    // "user", "assistant", "system"
-	Content interface{} `json:"content"` // string or []map[string]interface{}
+	Role       string      `json:"role"`                   // "user", "assistant", "system", "tool"
+	Content    interface{} `json:"content"`                // string or []map[string]interface{}
+	ToolCalls  []ToolCall  `json:"tool_calls,omitempty"`   // set on assistant messages that invoke tools
+	ToolCallID string      `json:"tool_call_id,omitempty"` // set on tool messages, matching the ToolCall.ID it answers
 }
 
 // ContentPart represents a part of multimodal content
@@ -50,6 +60,7 @@ type ChatCompletionChunk struct {
 	Created int64    `json:"created"`
 	Model   string   `json:"model"`
 	Choices []Choice `json:"choices"`
+	Usage   *Usage   `json:"usage,omitempty"` // only set on the final chunk when stream_options.include_usage is true
 }
 
 // Choice represents a completion choice
@@ -61,8 +72,9 @@ type Choice struct {
 
 // Delta represents the incremental content in a streaming response
 type Delta struct {
-	Role    string `json:"role,omitempty"`
-	Content string `json:"content,omitempty"`
+	Role      string     `json:"role,omitempty"`
+	Content   string     `json:"content,omitempty"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
 }
 
 // ChatCompletionResponse represents a non-streaming response
@@ -72,6 +84,7 @@ type ChatCompletionResponse struct {
 	Created int64           `json:"created"`
 	Model   string          `json:"model"`
 	Choices []MessageChoice `json:"choices"`
+	Usage   Usage           `json:"usage"`
 }
 
 // MessageChoice represents a complete message choice
@@ -0,0 +1,35 @@
+package models
+
+// Tool represents an OpenAI-compatible tool definition offered to the model
+type Tool struct {
+	Type     string       `json:"type"` // currently always "function"
+	Function ToolFunction `json:"function"`
+}
+
+// ToolFunction describes a callable function's name, description and JSON
+// Schema parameters
+type ToolFunction struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Parameters  interface{} `json:"parameters,omitempty"` // JSON Schema object
+}
+
+// ToolCall represents a single function call requested by the model
+type ToolCall struct {
+	ID       string           `json:"id"`
+	Type     string           `json:"type"` // currently always "function"
+	Function ToolCallFunction `json:"function"`
+	// Index is the call's position among the parallel tool calls in this
+	// choice. Streaming clients accumulate delta.tool_calls by index, so
+	// when more than one FunctionCall part is returned (parallel tool
+	// calls), each must carry its own index or clients will overwrite one
+	// call's delta with another's.
+	Index *int `json:"index,omitempty"`
+}
+
+// ToolCallFunction carries the invoked function's name and its arguments
+// encoded as a JSON string, matching OpenAI's wire format
+type ToolCallFunction struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"` // JSON-encoded arguments object
+}
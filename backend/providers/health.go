@@ -0,0 +1,144 @@
+package providers
+
+import (
+	"sync"
+	"time"
+)
+
+// unhealthyThreshold is the number of consecutive failures after which a
+// provider is marked unhealthy and skipped by the Router until it succeeds
+// again.
+const unhealthyThreshold = 3
+
+// cooldownPeriod is how long an unhealthy provider is skipped before the
+// Router gives it one more chance to prove it has recovered (a half-open
+// retry). Without this, a transient unhealthyThreshold-failure blip on a
+// single-provider deployment would take /v1/chat/completions down
+// permanently until process restart.
+const cooldownPeriod = 30 * time.Second
+
+// Status is a point-in-time snapshot of a provider's health, as reported by
+// the /health endpoint.
+type Status struct {
+	Name                string        `json:"name"`
+	Healthy             bool          `json:"healthy"`
+	ConsecutiveFailures int           `json:"consecutive_failures"`
+	AverageLatency      time.Duration `json:"average_latency_ns"`
+	LastError           string        `json:"last_error,omitempty"`
+	LastCheckedAt       time.Time     `json:"last_checked_at"`
+}
+
+// Tracker records per-provider success/failure outcomes and exposes the
+// rolling health Status the Router uses to skip failing providers.
+type Tracker struct {
+	mu        sync.Mutex
+	providers map[string]*providerStats
+}
+
+type providerStats struct {
+	consecutiveFailures int
+	totalLatency        time.Duration
+	samples             int
+	lastError           string
+	lastCheckedAt       time.Time
+}
+
+// NewTracker returns an empty Tracker; every provider starts healthy.
+func NewTracker() *Tracker {
+	return &Tracker{providers: make(map[string]*providerStats)}
+}
+
+// RecordSuccess resets a provider's failure streak and folds latency into
+// its rolling average.
+func (t *Tracker) RecordSuccess(name string, latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := t.stats(name)
+	stats.consecutiveFailures = 0
+	stats.lastError = ""
+	stats.totalLatency += latency
+	stats.samples++
+	stats.lastCheckedAt = time.Now()
+}
+
+// RecordFailure increments a provider's consecutive-failure streak,
+// eventually tripping Healthy to false once unhealthyThreshold is reached.
+func (t *Tracker) RecordFailure(name string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := t.stats(name)
+	stats.consecutiveFailures++
+	if err != nil {
+		stats.lastError = err.Error()
+	}
+	stats.lastCheckedAt = time.Now()
+}
+
+// Healthy reports whether a provider should still be tried: either it hasn't
+// hit unhealthyThreshold consecutive failures, or it has but cooldownPeriod
+// has passed since its last attempt, giving it a half-open retry.
+func (t *Tracker) Healthy(name string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return healthy(t.stats(name))
+}
+
+// healthy implements the Healthy policy shared by Tracker.Healthy and
+// Tracker.Status. Callers must hold t.mu.
+func healthy(stats *providerStats) bool {
+	if stats.consecutiveFailures < unhealthyThreshold {
+		return true
+	}
+	return time.Since(stats.lastCheckedAt) >= cooldownPeriod
+}
+
+// AverageLatency returns a provider's rolling average response latency, or
+// zero if it has no recorded successes yet.
+func (t *Tracker) AverageLatency(name string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := t.stats(name)
+	if stats.samples == 0 {
+		return 0
+	}
+	return stats.totalLatency / time.Duration(stats.samples)
+}
+
+// Status returns a snapshot of every provider this Tracker has seen activity
+// for.
+func (t *Tracker) Status() []Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	statuses := make([]Status, 0, len(t.providers))
+	for name, stats := range t.providers {
+		var avg time.Duration
+		if stats.samples > 0 {
+			avg = stats.totalLatency / time.Duration(stats.samples)
+		}
+		statuses = append(statuses, Status{
+			Name:                name,
+			Healthy:             healthy(stats),
+			ConsecutiveFailures: stats.consecutiveFailures,
+			AverageLatency:      avg,
+			LastError:           stats.lastError,
+			LastCheckedAt:       stats.lastCheckedAt,
+		})
+	}
+	return statuses
+}
+
+// stats returns (creating if necessary) the stats entry for name. Callers
+// must hold t.mu.
+func (t *Tracker) stats(name string) *providerStats {
+	stats, ok := t.providers[name]
+	if !ok {
+		stats = &providerStats{}
+		t.providers[name] = stats
+	}
+	return stats
+}
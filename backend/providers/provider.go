@@ -0,0 +1,30 @@
+// Package providers defines a common interface for LLM backends (Vertex,
+// Anthropic, OpenAI, Azure OpenAI, local Ollama, ...) and a Router that picks
+// among them per model alias with health-based failover.
+package providers
+
+import (
+	"context"
+	"net/http"
+
+	"google.golang.org/genai"
+)
+
+// Provider is a pluggable LLM backend capable of serving a chat completion
+// request in Vertex AI's Content/GenerateContentConfig shapes. Every
+// provider is expected to translate those shapes to and from its own wire
+// format internally, so the rest of the codebase (converters, SSE framing)
+// stays provider-agnostic.
+type Provider interface {
+	// Name identifies the provider for routing and health reporting (e.g.
+	// "vertex", "anthropic").
+	Name() string
+
+	// StreamResponse streams a completion as OpenAI-compatible SSE, matching
+	// vertexai.StreamResponse's signature.
+	StreamResponse(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig, includeUsage bool, w http.ResponseWriter) error
+
+	// NonStreamingResponse writes a single OpenAI-compatible JSON response,
+	// matching vertexai.NonStreamingResponse's signature.
+	NonStreamingResponse(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig, w http.ResponseWriter) error
+}
@@ -0,0 +1,215 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/genai"
+)
+
+// Strategy picks the order in which a Route's candidates are tried.
+type Strategy string
+
+const (
+	// StrategyPriority tries candidates in the order they were declared.
+	// This is the default and the only sensible choice for a fallback
+	// chain (e.g. "prefer vertex, fall back to anthropic").
+	StrategyPriority Strategy = "priority"
+	// StrategyRoundRobin rotates the starting candidate on every request,
+	// spreading load evenly across a route's candidates.
+	StrategyRoundRobin Strategy = "round-robin"
+	// StrategyLeastLatency tries the candidate with the lowest tracked
+	// average latency first.
+	StrategyLeastLatency Strategy = "least-latency"
+)
+
+// Candidate is one "provider/model" entry in a Route's fallback chain.
+type Candidate struct {
+	Provider string
+	Model    string
+}
+
+// Route is a model alias's ordered fallback chain, e.g. the alias
+// "gpt-fallback" might resolve to vertex/gemini-2.0-flash then
+// anthropic/claude-3-5-sonnet. Parsed from Config's MODEL_ROUTES.
+type Route struct {
+	Alias      string
+	Candidates []Candidate
+	Strategy   Strategy
+}
+
+// errNoHealthyProvider is returned when every candidate for a model is
+// currently unregistered or marked unhealthy.
+var errNoHealthyProvider = errors.New("no healthy provider available for model")
+
+// Router dispatches a chat completion to the first healthy candidate in a
+// model alias's fallback chain, transparently retrying the next candidate on
+// failure and recording outcomes in a Tracker so unhealthy providers are
+// skipped until they recover.
+//
+// Streaming fallback is best-effort: once a Provider has written anything to
+// w, a mid-stream failure can no longer be retried transparently (the client
+// has already received a partial response), so Dispatch tracks whether the
+// chosen Provider wrote anything via streamGuard and only falls back to the
+// next candidate when it didn't -- otherwise it returns the error as-is
+// rather than risk writing a second, concatenated response onto w.
+type Router struct {
+	providers     map[string]Provider
+	providerOrder []string
+	routes        map[string]Route
+	tracker       *Tracker
+	roundRobin    atomic.Uint64
+}
+
+// NewRouter builds a Router from a set of registered providers and routes
+// (as parsed from Config.ModelRoutes). A model name with no matching route
+// falls back to trying every registered provider, in registration order,
+// with that exact model name — so routing is optional for simple
+// single-provider deployments.
+func NewRouter(providerList []Provider, routes []Route) *Router {
+	r := &Router{
+		providers: make(map[string]Provider, len(providerList)),
+		routes:    make(map[string]Route, len(routes)),
+		tracker:   NewTracker(),
+	}
+	for _, p := range providerList {
+		r.providers[p.Name()] = p
+		r.providerOrder = append(r.providerOrder, p.Name())
+	}
+	for _, route := range routes {
+		r.routes[route.Alias] = route
+	}
+	return r
+}
+
+// Health returns a snapshot of every tracked provider's health, used to back
+// the /health endpoint.
+func (r *Router) Health() []Status {
+	return r.tracker.Status()
+}
+
+// Dispatch tries each candidate for modelName in order, skipping providers
+// the Tracker has marked unhealthy, and returns the response from the first
+// one that succeeds. stream selects whether StreamResponse or
+// NonStreamingResponse is invoked on the chosen Provider.
+func (r *Router) Dispatch(ctx context.Context, modelName string, contents []*genai.Content, config *genai.GenerateContentConfig, stream bool, includeUsage bool, w http.ResponseWriter) error {
+	candidates := r.resolve(modelName)
+	if len(candidates) == 0 {
+		return fmt.Errorf("no provider registered for model %q", modelName)
+	}
+
+	var lastErr error
+	tried := false
+
+	for _, candidate := range candidates {
+		provider, ok := r.providers[candidate.Provider]
+		if !ok {
+			continue
+		}
+		if !r.tracker.Healthy(candidate.Provider) {
+			continue
+		}
+
+		tried = true
+		start := time.Now()
+
+		if stream {
+			guard := &streamGuard{ResponseWriter: w}
+			err := provider.StreamResponse(ctx, candidate.Model, contents, config, includeUsage, guard)
+			latency := time.Since(start)
+
+			if err == nil {
+				r.tracker.RecordSuccess(candidate.Provider, latency)
+				return nil
+			}
+
+			r.tracker.RecordFailure(candidate.Provider, err)
+			if guard.wrote {
+				// The client already has a partial response; falling back
+				// to another candidate would write a second, concatenated
+				// stream onto the same connection.
+				return fmt.Errorf("provider %q failed mid-stream: %w", candidate.Provider, err)
+			}
+			lastErr = err
+			continue
+		}
+
+		err := provider.NonStreamingResponse(ctx, candidate.Model, contents, config, w)
+		latency := time.Since(start)
+
+		if err == nil {
+			r.tracker.RecordSuccess(candidate.Provider, latency)
+			return nil
+		}
+
+		r.tracker.RecordFailure(candidate.Provider, err)
+		lastErr = err
+	}
+
+	if !tried {
+		return errNoHealthyProvider
+	}
+	return fmt.Errorf("all providers exhausted for model %q: %w", modelName, lastErr)
+}
+
+// streamGuard wraps an http.ResponseWriter to track whether anything has
+// been written to it yet, so Dispatch can tell whether a mid-stream failure
+// is still safe to fall back from.
+type streamGuard struct {
+	http.ResponseWriter
+	wrote bool
+}
+
+func (g *streamGuard) Write(b []byte) (int, error) {
+	if len(b) > 0 {
+		g.wrote = true
+	}
+	return g.ResponseWriter.Write(b)
+}
+
+func (g *streamGuard) WriteHeader(status int) {
+	g.wrote = true
+	g.ResponseWriter.WriteHeader(status)
+}
+
+// Flush satisfies http.Flusher so StreamResponse's SSE flushing still works
+// through the guard, delegating to the underlying writer when it supports
+// flushing.
+func (g *streamGuard) Flush() {
+	if flusher, ok := g.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// resolve returns the ordered candidates to try for a requested model name.
+func (r *Router) resolve(modelName string) []Candidate {
+	route, ok := r.routes[modelName]
+	if !ok {
+		candidates := make([]Candidate, len(r.providerOrder))
+		for i, name := range r.providerOrder {
+			candidates[i] = Candidate{Provider: name, Model: modelName}
+		}
+		return candidates
+	}
+
+	candidates := append([]Candidate(nil), route.Candidates...)
+
+	switch route.Strategy {
+	case StrategyRoundRobin:
+		if len(candidates) > 0 {
+			start := int(r.roundRobin.Add(1)-1) % len(candidates)
+			candidates = append(candidates[start:], candidates[:start]...)
+		}
+	case StrategyLeastLatency:
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return r.tracker.AverageLatency(candidates[i].Provider) < r.tracker.AverageLatency(candidates[j].Provider)
+		})
+	}
+
+	return candidates
+}
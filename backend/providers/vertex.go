@@ -0,0 +1,34 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/owulveryck/agentflowui/backend/vertexai"
+	"google.golang.org/genai"
+)
+
+// VertexProvider adapts the vertexai package's Stream/NonStreamingResponse
+// functions to the Provider interface.
+type VertexProvider struct {
+	client *genai.Client
+}
+
+// NewVertexProvider wraps an already-initialized Vertex AI client as a
+// Provider named "vertex".
+func NewVertexProvider(client *genai.Client) *VertexProvider {
+	return &VertexProvider{client: client}
+}
+
+// Name implements Provider.
+func (p *VertexProvider) Name() string { return "vertex" }
+
+// StreamResponse implements Provider.
+func (p *VertexProvider) StreamResponse(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig, includeUsage bool, w http.ResponseWriter) error {
+	return vertexai.StreamResponse(ctx, p.client, model, contents, config, includeUsage, w)
+}
+
+// NonStreamingResponse implements Provider.
+func (p *VertexProvider) NonStreamingResponse(ctx context.Context, model string, contents []*genai.Content, config *genai.GenerateContentConfig, w http.ResponseWriter) error {
+	return vertexai.NonStreamingResponse(ctx, p.client, model, contents, config, w)
+}
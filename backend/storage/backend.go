@@ -0,0 +1,61 @@
+// Package storage abstracts fetching multimodal content (images, audio,
+// files) referenced by scheme-prefixed URLs such as gdrive://, s3://,
+// http(s)://, and file://, so the API layer isn't limited to Google Drive.
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+// Backend fetches content referenced by a URL scheme-specific reference,
+// e.g. a gdrive:// file ID or an s3://bucket/key path.
+type Backend interface {
+	// Scheme returns the URL scheme this backend handles, e.g. "gdrive".
+	Scheme() string
+
+	// Fetch downloads the full content referenced by ref. authToken is
+	// backend-specific (an OAuth bearer token for gdrive, static
+	// credentials for s3, etc) and may be empty when the backend is
+	// configured with server-side credentials.
+	Fetch(ctx context.Context, ref, authToken string) (io.ReadCloser, string, error)
+
+	// Head returns the MIME type and size of the content referenced by
+	// ref without downloading it.
+	Head(ctx context.Context, ref, authToken string) (mimeType string, size int64, err error)
+}
+
+// Registry dispatches a content URL to the Backend registered for its
+// scheme.
+type Registry struct {
+	backends map[string]Backend
+}
+
+// NewRegistry creates an empty backend registry.
+func NewRegistry() *Registry {
+	return &Registry{backends: make(map[string]Backend)}
+}
+
+// Register adds a backend, keyed by its Scheme().
+func (r *Registry) Register(backend Backend) {
+	r.backends[backend.Scheme()] = backend
+}
+
+// Lookup returns the backend registered for a URL's scheme along with the
+// scheme-specific reference (the URL with "scheme://" stripped).
+func (r *Registry) Lookup(url string) (backend Backend, ref string, ok bool) {
+	for scheme, b := range r.backends {
+		prefix := scheme + "://"
+		if strings.HasPrefix(url, prefix) {
+			return b, strings.TrimPrefix(url, prefix), true
+		}
+	}
+	return nil, "", false
+}
+
+// IsManagedURL reports whether url is handled by any registered backend.
+func (r *Registry) IsManagedURL(url string) bool {
+	_, _, ok := r.Lookup(url)
+	return ok
+}
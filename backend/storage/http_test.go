@@ -0,0 +1,185 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestHTTPBackendCheckAllowed(t *testing.T) {
+	b := NewHTTPBackend("https", http.DefaultClient, []string{"allowed.example.com"})
+
+	if err := b.checkAllowed("https://allowed.example.com/file.pdf"); err != nil {
+		t.Errorf("expected allowed host to pass, got error: %v", err)
+	}
+	if err := b.checkAllowed("https://not-allowed.example.com/file.pdf"); err == nil {
+		t.Errorf("expected host outside the allow-list to be rejected")
+	}
+}
+
+func TestHTTPBackendCheckAllowedCaseInsensitive(t *testing.T) {
+	b := NewHTTPBackend("https", http.DefaultClient, []string{"Allowed.Example.com"})
+
+	if err := b.checkAllowed("https://allowed.example.com/file.pdf"); err != nil {
+		t.Errorf("expected host matching to be case-insensitive, got error: %v", err)
+	}
+}
+
+func TestHTTPBackendEmptyAllowListRejectsEverything(t *testing.T) {
+	b := NewHTTPBackend("https", http.DefaultClient, nil)
+
+	if err := b.checkAllowed("https://anything.example.com/file.pdf"); err == nil {
+		t.Errorf("expected an empty allow-list to reject every host")
+	}
+}
+
+func TestHTTPBackendFetchRejectsDisallowedHost(t *testing.T) {
+	b := NewHTTPBackend("https", http.DefaultClient, []string{"allowed.example.com"})
+
+	_, _, err := b.Fetch(context.Background(), "not-allowed.example.com/file.pdf", "")
+	if err == nil {
+		t.Errorf("expected Fetch to reject a host outside the allow-list")
+	}
+}
+
+func TestHTTPBackendFetchAllowedHost(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+
+	b := NewHTTPBackend("http", srv.Client(), []string{mustHostname(t, srv.URL)})
+
+	reader, mimeType, err := b.Fetch(context.Background(), mustHostPort(t, srv.URL), "")
+	if err != nil {
+		t.Fatalf("Fetch returned error: %v", err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	if string(data) != "hello" {
+		t.Errorf("body = %q, want %q", data, "hello")
+	}
+	if mimeType != "text/plain" {
+		t.Errorf("mimeType = %q, want %q", mimeType, "text/plain")
+	}
+}
+
+func TestHTTPBackendCheckRedirectRejectsDisallowedHost(t *testing.T) {
+	b := NewHTTPBackend("https", http.DefaultClient, []string{"allowed.example.com"})
+
+	req, err := http.NewRequest(http.MethodGet, "https://evil.example.com/", nil)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	if err := b.checkRedirect(req, nil); err == nil {
+		t.Errorf("expected a redirect to a host outside the allow-list to be blocked")
+	}
+}
+
+func TestHTTPBackendCheckRedirectAllowsAllowedHost(t *testing.T) {
+	b := NewHTTPBackend("https", http.DefaultClient, []string{"allowed.example.com"})
+
+	req, err := http.NewRequest(http.MethodGet, "https://allowed.example.com/other", nil)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	if err := b.checkRedirect(req, nil); err != nil {
+		t.Errorf("expected a redirect to an allow-listed host to pass, got: %v", err)
+	}
+}
+
+func TestHTTPBackendCheckRedirectStopsAfterTenHops(t *testing.T) {
+	b := NewHTTPBackend("https", http.DefaultClient, []string{"allowed.example.com"})
+
+	req, err := http.NewRequest(http.MethodGet, "https://allowed.example.com/", nil)
+	if err != nil {
+		t.Fatalf("failed to build request: %v", err)
+	}
+	via := make([]*http.Request, 10)
+	for i := range via {
+		via[i] = req
+	}
+	if err := b.checkRedirect(req, via); err == nil {
+		t.Errorf("expected the redirect chain to be capped at 10 hops")
+	}
+}
+
+func TestHTTPBackendRedirectToDisallowedHostIsBlockedEndToEnd(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://disallowed.invalid/", http.StatusFound)
+	}))
+	defer srv.Close()
+
+	b := NewHTTPBackend("http", srv.Client(), []string{mustHostname(t, srv.URL)})
+
+	_, _, err := b.Fetch(context.Background(), mustHostPort(t, srv.URL), "")
+	if err == nil {
+		t.Errorf("expected a redirect to a host outside the allow-list to be blocked")
+	}
+}
+
+func TestHTTPBackendRedirectToAllowedHostSucceeds(t *testing.T) {
+	var target *httptest.Server
+	target = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("final"))
+	}))
+	defer target.Close()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, target.URL, http.StatusFound)
+	}))
+	defer srv.Close()
+
+	b := NewHTTPBackend("http", srv.Client(), []string{mustHostname(t, srv.URL), mustHostname(t, target.URL)})
+
+	reader, _, err := b.Fetch(context.Background(), mustHostPort(t, srv.URL), "")
+	if err != nil {
+		t.Fatalf("expected a redirect to an allow-listed host to succeed, got error: %v", err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("failed to read body: %v", err)
+	}
+	if string(data) != "final" {
+		t.Errorf("body = %q, want %q", data, "final")
+	}
+}
+
+func TestHTTPBackendSharedClientGetsIndependentRedirectPolicy(t *testing.T) {
+	shared := &http.Client{}
+
+	NewHTTPBackend("http", shared, []string{"first.example.com"})
+	NewHTTPBackend("https", shared, []string{"second.example.com"})
+
+	if shared.CheckRedirect != nil {
+		t.Errorf("expected the caller's original *http.Client to be left untouched, not mutated in place")
+	}
+}
+
+func mustHostPort(t *testing.T, rawURL string) string {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("failed to parse %q: %v", rawURL, err)
+	}
+	return u.Host
+}
+
+func mustHostname(t *testing.T, rawURL string) string {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatalf("failed to parse %q: %v", rawURL, err)
+	}
+	return u.Hostname()
+}
@@ -0,0 +1,117 @@
+package vertexai
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/owulveryck/agentflowui/backend/models"
+	"google.golang.org/genai"
+)
+
+// Gemini TTS always emits raw signed 16-bit little-endian PCM at this sample
+// rate and channel count; there's no per-request way to change it.
+const (
+	speechSampleRate    = 24000
+	speechBitsPerSample = 16
+	speechChannels      = 1
+)
+
+// Transcribe sends audio bytes through Vertex AI's Gemini audio-in path and
+// returns the transcribed text.
+func Transcribe(ctx context.Context, client *genai.Client, model string, audioData []byte, mimeType, language string) (*models.TranscriptionResponse, error) {
+	prompt := "Transcribe the following audio verbatim."
+	if language != "" {
+		prompt = fmt.Sprintf("Transcribe the following audio verbatim. The spoken language is %s.", language)
+	}
+
+	contents := []*genai.Content{
+		{
+			Parts: []*genai.Part{
+				{Text: prompt},
+				{InlineData: &genai.Blob{Data: audioData, MIMEType: mimeType}},
+			},
+		},
+	}
+
+	resp, err := client.Models.GenerateContent(ctx, model, contents, nil)
+	if err != nil {
+		return nil, fmt.Errorf("transcription error: %w", err)
+	}
+
+	var text string
+	if len(resp.Candidates) > 0 && resp.Candidates[0].Content != nil {
+		for _, part := range resp.Candidates[0].Content.Parts {
+			text += part.Text
+		}
+	}
+
+	return &models.TranscriptionResponse{Text: text}, nil
+}
+
+// Synthesize sends text through Vertex AI TTS and returns the synthesized
+// audio bytes. The bytes are raw PCM (speechSampleRate/speechBitsPerSample/
+// speechChannels) -- Gemini TTS doesn't support requesting an encoded output
+// format, so callers wanting something other than raw PCM must transcode
+// (see EncodeWAV for the one format this package can produce without an
+// external codec) or reject the request.
+func Synthesize(ctx context.Context, client *genai.Client, model, input, voice string) ([]byte, error) {
+	contents := []*genai.Content{
+		{Parts: []*genai.Part{{Text: input}}},
+	}
+
+	config := &genai.GenerateContentConfig{
+		ResponseModalities: []string{"AUDIO"},
+		SpeechConfig: &genai.SpeechConfig{
+			VoiceConfig: &genai.VoiceConfig{
+				PrebuiltVoiceConfig: &genai.PrebuiltVoiceConfig{VoiceName: voice},
+			},
+		},
+	}
+
+	resp, err := client.Models.GenerateContent(ctx, model, contents, config)
+	if err != nil {
+		return nil, fmt.Errorf("speech synthesis error: %w", err)
+	}
+
+	if len(resp.Candidates) == 0 || resp.Candidates[0].Content == nil {
+		return nil, fmt.Errorf("no audio returned for synthesis")
+	}
+
+	for _, part := range resp.Candidates[0].Content.Parts {
+		if part.InlineData != nil {
+			return part.InlineData.Data, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no audio part returned for synthesis")
+}
+
+// EncodeWAV wraps raw PCM bytes (as returned by Synthesize) in a standard
+// RIFF/WAVE header, so the result is a real, playable .wav file rather than
+// raw PCM mislabeled as one.
+func EncodeWAV(pcm []byte) []byte {
+	var header bytes.Buffer
+
+	byteRate := speechSampleRate * speechChannels * speechBitsPerSample / 8
+	blockAlign := speechChannels * speechBitsPerSample / 8
+
+	header.WriteString("RIFF")
+	binary.Write(&header, binary.LittleEndian, uint32(36+len(pcm)))
+	header.WriteString("WAVE")
+
+	header.WriteString("fmt ")
+	binary.Write(&header, binary.LittleEndian, uint32(16)) // PCM fmt chunk size
+	binary.Write(&header, binary.LittleEndian, uint16(1))  // audio format: PCM
+	binary.Write(&header, binary.LittleEndian, uint16(speechChannels))
+	binary.Write(&header, binary.LittleEndian, uint32(speechSampleRate))
+	binary.Write(&header, binary.LittleEndian, uint32(byteRate))
+	binary.Write(&header, binary.LittleEndian, uint16(blockAlign))
+	binary.Write(&header, binary.LittleEndian, uint16(speechBitsPerSample))
+
+	header.WriteString("data")
+	binary.Write(&header, binary.LittleEndian, uint32(len(pcm)))
+
+	return append(header.Bytes(), pcm...)
+}
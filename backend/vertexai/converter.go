@@ -10,109 +10,310 @@ import (
 	"google.golang.org/genai"
 )
 
-// ConvertToVertexAI converts OpenAI-format messages to Vertex AI GenAI format
+// ConvertToVertexAI converts OpenAI-format messages to Vertex AI GenAI format.
+//
+// Deprecated: kept for callers that don't need tool calling or system
+// instructions; it silently drops system messages. Use ConvertRequest instead.
 func ConvertToVertexAI(messages []models.Message) ([]*genai.Content, error) {
+	contents, _, err := convertMessages(messages)
+	return contents, err
+}
+
+// ConvertRequest converts an OpenAI-compatible chat completion request to
+// Vertex AI's Content/GenerateContentConfig shapes, threading system
+// messages into SystemInstruction and req.Tools into the config's function
+// declarations so the model can perform tool calling.
+func ConvertRequest(req *models.ChatCompletionRequest) ([]*genai.Content, *genai.GenerateContentConfig, error) {
+	contents, systemInstruction, err := convertMessages(req.Messages)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var config *genai.GenerateContentConfig
+	if systemInstruction != nil || len(req.Tools) > 0 {
+		config = &genai.GenerateContentConfig{SystemInstruction: systemInstruction}
+		if len(req.Tools) > 0 {
+			tools, err := convertTools(req.Tools)
+			if err != nil {
+				return nil, nil, err
+			}
+			config.Tools = tools
+			config.ToolConfig = convertToolChoice(req.ToolChoice)
+		}
+	}
+
+	return contents, config, nil
+}
+
+// convertToolChoice maps OpenAI's tool_choice ("auto" | "none" | "required"
+// | {"type":"function","function":{"name":...}}) to Vertex AI's
+// FunctionCallingConfig. A nil or unrecognized value leaves the mode at its
+// SDK default (AUTO).
+func convertToolChoice(toolChoice interface{}) *genai.ToolConfig {
+	switch v := toolChoice.(type) {
+	case string:
+		switch v {
+		case "none":
+			return &genai.ToolConfig{FunctionCallingConfig: &genai.FunctionCallingConfig{Mode: genai.FunctionCallingConfigModeNone}}
+		case "required":
+			return &genai.ToolConfig{FunctionCallingConfig: &genai.FunctionCallingConfig{Mode: genai.FunctionCallingConfigModeAny}}
+		default:
+			return nil
+		}
+
+	case map[string]interface{}:
+		function, ok := v["function"].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		name, ok := function["name"].(string)
+		if !ok {
+			return nil
+		}
+		return &genai.ToolConfig{
+			FunctionCallingConfig: &genai.FunctionCallingConfig{
+				Mode:                 genai.FunctionCallingConfigModeAny,
+				AllowedFunctionNames: []string{name},
+			},
+		}
+
+	default:
+		return nil
+	}
+}
+
+// convertMessages converts OpenAI-format messages to Vertex AI Content,
+// collecting any system messages into a single SystemInstruction Content
+// instead of dropping them.
+func convertMessages(messages []models.Message) ([]*genai.Content, *genai.Content, error) {
 	contents := make([]*genai.Content, 0, len(messages))
+	var systemParts []*genai.Part
+	// toolCallNames maps an assistant message's ToolCall.ID to the function
+	// name it called, so a later "tool" role message (identified only by
+	// ToolCallID, per the OpenAI shape) can report its FunctionResponse under
+	// the name Gemini actually expects.
+	toolCallNames := make(map[string]string)
 
 	for _, msg := range messages {
-		parts := make([]*genai.Part, 0)
-
-		// Handle string content (simple text message)
-		if text, ok := msg.Content.(string); ok {
-			parts = append(parts, &genai.Part{Text: text})
-		} else {
-			// Handle multimodal content (array of content parts)
-			// The JSON unmarshaler will give us []interface{} containing map[string]interface{}
-			contentArray, ok := msg.Content.([]interface{})
-			if !ok {
-				return nil, fmt.Errorf("invalid message content type: %T", msg.Content)
+		if msg.Role == "tool" {
+			parts, err := toolResponseParts(msg, toolCallNames[msg.ToolCallID])
+			if err != nil {
+				return nil, nil, err
 			}
+			if len(parts) > 0 {
+				contents = append(contents, &genai.Content{Role: "user", Parts: parts})
+			}
+			continue
+		}
 
-			for _, item := range contentArray {
-				contentMap, ok := item.(map[string]interface{})
-				if !ok {
-					continue
+		parts, err := contentParts(msg.Content)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, call := range msg.ToolCalls {
+			args := map[string]interface{}{}
+			if call.Function.Arguments != "" {
+				if err := json.Unmarshal([]byte(call.Function.Arguments), &args); err != nil {
+					return nil, nil, fmt.Errorf("invalid tool call arguments for %s: %w", call.Function.Name, err)
 				}
+			}
+			toolCallNames[call.ID] = call.Function.Name
+			parts = append(parts, &genai.Part{
+				FunctionCall: &genai.FunctionCall{Name: call.Function.Name, Args: args},
+			})
+		}
 
-				partType, _ := contentMap["type"].(string)
+		if len(parts) == 0 {
+			continue
+		}
 
-				switch partType {
-				case "text":
-					if textVal, ok := contentMap["text"].(string); ok {
-						parts = append(parts, &genai.Part{Text: textVal})
-					}
+		if msg.Role == "system" {
+			systemParts = append(systemParts, parts...)
+			continue
+		}
+
+		role := msg.Role
+		if role == "assistant" {
+			role = "model"
+		}
+
+		contents = append(contents, &genai.Content{Role: role, Parts: parts})
+	}
+
+	var systemInstruction *genai.Content
+	if len(systemParts) > 0 {
+		systemInstruction = &genai.Content{Parts: systemParts}
+	}
+
+	return contents, systemInstruction, nil
+}
+
+// toolResponseParts converts a "tool" role message (a function's result,
+// matched to its ToolCall.ID) into a Vertex AI FunctionResponse part.
+// functionName is the name of the function that was called, looked up from
+// the preceding assistant message's ToolCalls by ToolCallID -- Gemini
+// matches a FunctionResponse to its pending FunctionCall by name, not by the
+// OpenAI-style opaque tool_call_id, so msg.ToolCallID can't be used directly
+// here. If functionName is empty (e.g. the call wasn't found, perhaps
+// because the conversation history was truncated), it's used as a
+// best-effort fallback.
+func toolResponseParts(msg models.Message, functionName string) ([]*genai.Part, error) {
+	text, ok := msg.Content.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid tool message content type: %T", msg.Content)
+	}
 
-				case "image_url":
-					if imageURL, ok := contentMap["image_url"].(map[string]interface{}); ok {
-						if url, ok := imageURL["url"].(string); ok {
-							data, mimeType, err := parseDataURL(url)
-							if err != nil {
-								return nil, fmt.Errorf("failed to parse image data URL: %w", err)
-							}
-							parts = append(parts, &genai.Part{
-								InlineData: &genai.Blob{
-									Data:     data,
-									MIMEType: mimeType,
-								},
-							})
-						}
+	if functionName == "" {
+		functionName = msg.ToolCallID
+	}
+
+	response := map[string]interface{}{"content": text}
+
+	return []*genai.Part{
+		{
+			FunctionResponse: &genai.FunctionResponse{
+				Name:     functionName,
+				Response: response,
+			},
+		},
+	}, nil
+}
+
+// contentParts converts a single message's Content field (either a plain
+// string or an array of multimodal content parts) into Vertex AI Parts.
+func contentParts(content interface{}) ([]*genai.Part, error) {
+	parts := make([]*genai.Part, 0)
+
+	// Handle string content (simple text message)
+	if text, ok := content.(string); ok {
+		parts = append(parts, &genai.Part{Text: text})
+		return parts, nil
+	}
+
+	// Handle multimodal content (array of content parts)
+	// The JSON unmarshaler will give us []interface{} containing map[string]interface{}
+	contentArray, ok := content.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid message content type: %T", content)
+	}
+
+	for _, item := range contentArray {
+		contentMap, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		partType, _ := contentMap["type"].(string)
+
+		switch partType {
+		case "text":
+			if textVal, ok := contentMap["text"].(string); ok {
+				parts = append(parts, &genai.Part{Text: textVal})
+			}
+
+		case "image_url":
+			if imageURL, ok := contentMap["image_url"].(map[string]interface{}); ok {
+				if url, ok := imageURL["url"].(string); ok {
+					data, mimeType, err := parseDataURL(url)
+					if err != nil {
+						return nil, fmt.Errorf("failed to parse image data URL: %w", err)
 					}
+					parts = append(parts, &genai.Part{
+						InlineData: &genai.Blob{
+							Data:     data,
+							MIMEType: mimeType,
+						},
+					})
+				}
+			}
 
-				case "audio":
-					if audio, ok := contentMap["audio"].(map[string]interface{}); ok {
-						if dataStr, ok := audio["data"].(string); ok {
-							data, mimeType, err := parseDataURL(dataStr)
-							if err != nil {
-								return nil, fmt.Errorf("failed to parse audio data URL: %w", err)
-							}
-							parts = append(parts, &genai.Part{
-								InlineData: &genai.Blob{
-									Data:     data,
-									MIMEType: mimeType,
-								},
-							})
-						}
+		case "audio":
+			if audio, ok := contentMap["audio"].(map[string]interface{}); ok {
+				if dataStr, ok := audio["data"].(string); ok {
+					data, mimeType, err := parseDataURL(dataStr)
+					if err != nil {
+						return nil, fmt.Errorf("failed to parse audio data URL: %w", err)
 					}
+					parts = append(parts, &genai.Part{
+						InlineData: &genai.Blob{
+							Data:     data,
+							MIMEType: mimeType,
+						},
+					})
+				}
+			}
 
-				case "file":
-					if file, ok := contentMap["file"].(map[string]interface{}); ok {
-						if fileData, ok := file["file_data"].(string); ok {
-							data, mimeType, err := parseDataURL(fileData)
-							if err != nil {
-								return nil, fmt.Errorf("failed to parse file data URL: %w", err)
-							}
-							parts = append(parts, &genai.Part{
-								InlineData: &genai.Blob{
-									Data:     data,
-									MIMEType: mimeType,
-								},
-							})
-						}
+		case "file":
+			if file, ok := contentMap["file"].(map[string]interface{}); ok {
+				if fileData, ok := file["file_data"].(string); ok {
+					data, mimeType, err := parseDataURL(fileData)
+					if err != nil {
+						return nil, fmt.Errorf("failed to parse file data URL: %w", err)
 					}
+					parts = append(parts, &genai.Part{
+						InlineData: &genai.Blob{
+							Data:     data,
+							MIMEType: mimeType,
+						},
+					})
 				}
 			}
 		}
+	}
 
-		// Only add content if it has parts
-		if len(parts) > 0 {
-			// Map OpenAI roles to Vertex AI roles
-			role := msg.Role
-			if role == "assistant" {
-				role = "model"
-			}
-			// Skip system messages as they're handled differently in Vertex AI
-			if msg.Role == "system" {
-				continue
-			}
+	return parts, nil
+}
 
-			contents = append(contents, &genai.Content{
-				Role:  role,
-				Parts: parts,
-			})
+// convertTools converts OpenAI-style tool definitions into a single Vertex
+// AI Tool carrying one FunctionDeclaration per entry, which is how the
+// genai SDK expects function calling to be configured.
+func convertTools(tools []models.Tool) ([]*genai.Tool, error) {
+	declarations := make([]*genai.FunctionDeclaration, 0, len(tools))
+
+	for _, t := range tools {
+		if t.Type != "" && t.Type != "function" {
+			return nil, fmt.Errorf("unsupported tool type %q", t.Type)
 		}
+
+		schema, err := convertParametersSchema(t.Function.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parameters schema for tool %q: %w", t.Function.Name, err)
+		}
+
+		declarations = append(declarations, &genai.FunctionDeclaration{
+			Name:        t.Function.Name,
+			Description: t.Function.Description,
+			Parameters:  schema,
+		})
+	}
+
+	if len(declarations) == 0 {
+		return nil, nil
+	}
+
+	return []*genai.Tool{{FunctionDeclarations: declarations}}, nil
+}
+
+// convertParametersSchema converts a tool's JSON Schema parameters (decoded
+// by encoding/json into map[string]interface{}) into a genai.Schema by
+// round-tripping through JSON, since the shapes are structurally compatible.
+func convertParametersSchema(parameters interface{}) (*genai.Schema, error) {
+	if parameters == nil {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	var schema genai.Schema
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return nil, err
 	}
 
-	return contents, nil
+	return &schema, nil
 }
 
 // parseDataURL extracts binary data and MIME type from a data URL
@@ -0,0 +1,179 @@
+package vertexai
+
+import (
+	"testing"
+
+	"github.com/owulveryck/agentflowui/backend/models"
+)
+
+func TestConvertMessagesMatchesToolResponseByFunctionName(t *testing.T) {
+	messages := []models.Message{
+		{Role: "user", Content: "what's the weather?"},
+		{
+			Role:    "assistant",
+			Content: "",
+			ToolCalls: []models.ToolCall{
+				{ID: "call_123", Function: models.ToolCallFunction{Name: "get_weather", Arguments: `{"city":"paris"}`}},
+			},
+		},
+		{Role: "tool", ToolCallID: "call_123", Content: "22C and sunny"},
+	}
+
+	contents, _, err := convertMessages(messages)
+	if err != nil {
+		t.Fatalf("convertMessages returned error: %v", err)
+	}
+
+	var found bool
+	for _, c := range contents {
+		for _, p := range c.Parts {
+			if p.FunctionResponse != nil {
+				found = true
+				if p.FunctionResponse.Name != "get_weather" {
+					t.Errorf("FunctionResponse.Name = %q, want %q (the function name, not the tool_call_id)", p.FunctionResponse.Name, "get_weather")
+				}
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("expected a FunctionResponse part in the converted contents")
+	}
+}
+
+func TestConvertMessagesToolResponseFallsBackToToolCallID(t *testing.T) {
+	messages := []models.Message{
+		{Role: "tool", ToolCallID: "call_unknown", Content: "result"},
+	}
+
+	contents, _, err := convertMessages(messages)
+	if err != nil {
+		t.Fatalf("convertMessages returned error: %v", err)
+	}
+
+	if len(contents) != 1 || len(contents[0].Parts) != 1 || contents[0].Parts[0].FunctionResponse == nil {
+		t.Fatalf("expected a single FunctionResponse part")
+	}
+	if got := contents[0].Parts[0].FunctionResponse.Name; got != "call_unknown" {
+		t.Errorf("FunctionResponse.Name = %q, want fallback to tool_call_id %q", got, "call_unknown")
+	}
+}
+
+func TestConvertMessagesCollectsSystemInstruction(t *testing.T) {
+	messages := []models.Message{
+		{Role: "system", Content: "be concise"},
+		{Role: "user", Content: "hi"},
+	}
+
+	contents, systemInstruction, err := convertMessages(messages)
+	if err != nil {
+		t.Fatalf("convertMessages returned error: %v", err)
+	}
+
+	if len(contents) != 1 {
+		t.Fatalf("expected the system message to be excluded from contents, got %d entries", len(contents))
+	}
+	if systemInstruction == nil || len(systemInstruction.Parts) != 1 || systemInstruction.Parts[0].Text != "be concise" {
+		t.Fatalf("expected systemInstruction to carry the system message text")
+	}
+}
+
+func TestConvertMessagesMapsAssistantRoleToModel(t *testing.T) {
+	messages := []models.Message{
+		{Role: "assistant", Content: "hello"},
+	}
+
+	contents, _, err := convertMessages(messages)
+	if err != nil {
+		t.Fatalf("convertMessages returned error: %v", err)
+	}
+	if len(contents) != 1 || contents[0].Role != "model" {
+		t.Fatalf("expected assistant role to map to \"model\", got %+v", contents)
+	}
+}
+
+func TestConvertMessagesRejectsInvalidToolCallArguments(t *testing.T) {
+	messages := []models.Message{
+		{
+			Role:    "assistant",
+			Content: "",
+			ToolCalls: []models.ToolCall{
+				{ID: "call_1", Function: models.ToolCallFunction{Name: "f", Arguments: "not json"}},
+			},
+		},
+	}
+
+	if _, _, err := convertMessages(messages); err == nil {
+		t.Errorf("expected invalid tool call arguments to produce an error")
+	}
+}
+
+func TestConvertToolChoice(t *testing.T) {
+	if cfg := convertToolChoice("none"); cfg == nil || cfg.FunctionCallingConfig.Mode != "NONE" {
+		t.Errorf("convertToolChoice(\"none\") = %+v, want Mode NONE", cfg)
+	}
+	if cfg := convertToolChoice("required"); cfg == nil || cfg.FunctionCallingConfig.Mode != "ANY" {
+		t.Errorf("convertToolChoice(\"required\") = %+v, want Mode ANY", cfg)
+	}
+	if cfg := convertToolChoice("auto"); cfg != nil {
+		t.Errorf("convertToolChoice(\"auto\") = %+v, want nil (SDK default)", cfg)
+	}
+	if cfg := convertToolChoice(nil); cfg != nil {
+		t.Errorf("convertToolChoice(nil) = %+v, want nil", cfg)
+	}
+
+	named := map[string]interface{}{
+		"type":     "function",
+		"function": map[string]interface{}{"name": "get_weather"},
+	}
+	cfg := convertToolChoice(named)
+	if cfg == nil || len(cfg.FunctionCallingConfig.AllowedFunctionNames) != 1 || cfg.FunctionCallingConfig.AllowedFunctionNames[0] != "get_weather" {
+		t.Errorf("convertToolChoice(named function) = %+v, want AllowedFunctionNames=[get_weather]", cfg)
+	}
+}
+
+func TestConvertToolsBuildsFunctionDeclarations(t *testing.T) {
+	tools := []models.Tool{
+		{Type: "function", Function: models.ToolFunction{
+			Name:        "get_weather",
+			Description: "gets the weather",
+			Parameters:  map[string]interface{}{"type": "object"},
+		}},
+	}
+
+	converted, err := convertTools(tools)
+	if err != nil {
+		t.Fatalf("convertTools returned error: %v", err)
+	}
+	if len(converted) != 1 || len(converted[0].FunctionDeclarations) != 1 {
+		t.Fatalf("expected a single Tool with one FunctionDeclaration, got %+v", converted)
+	}
+	if got := converted[0].FunctionDeclarations[0].Name; got != "get_weather" {
+		t.Errorf("FunctionDeclaration.Name = %q, want %q", got, "get_weather")
+	}
+}
+
+func TestConvertToolsRejectsUnsupportedType(t *testing.T) {
+	tools := []models.Tool{{Type: "retrieval"}}
+	if _, err := convertTools(tools); err == nil {
+		t.Errorf("expected an unsupported tool type to produce an error")
+	}
+}
+
+func TestParseDataURL(t *testing.T) {
+	data, mimeType, err := parseDataURL("data:image/png;base64,aGVsbG8=")
+	if err != nil {
+		t.Fatalf("parseDataURL returned error: %v", err)
+	}
+	if string(data) != "hello" {
+		t.Errorf("data = %q, want %q", data, "hello")
+	}
+	if mimeType != "image/png" {
+		t.Errorf("mimeType = %q, want %q", mimeType, "image/png")
+	}
+}
+
+func TestParseDataURLRejectsMissingPrefix(t *testing.T) {
+	if _, _, err := parseDataURL("aGVsbG8="); err == nil {
+		t.Errorf("expected a data URL missing the data: prefix to be rejected")
+	}
+}
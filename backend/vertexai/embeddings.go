@@ -0,0 +1,49 @@
+package vertexai
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/owulveryck/agentflowui/backend/models"
+	"google.golang.org/genai"
+)
+
+// Embed generates embeddings for one or more text inputs using a Vertex AI
+// embedding model (e.g. text-embedding-004).
+func Embed(ctx context.Context, client *genai.Client, model string, inputs []string) (*models.EmbeddingsResponse, error) {
+	contents := make([]*genai.Content, len(inputs))
+	for i, input := range inputs {
+		contents[i] = &genai.Content{Parts: []*genai.Part{{Text: input}}}
+	}
+
+	resp, err := client.Models.EmbedContent(ctx, model, contents, nil)
+	if err != nil {
+		return nil, fmt.Errorf("embed content error: %w", err)
+	}
+
+	data := make([]models.EmbeddingData, len(resp.Embeddings))
+	for i, embedding := range resp.Embeddings {
+		data[i] = models.EmbeddingData{
+			Embedding: embedding.Values,
+			Index:     i,
+			Object:    "embedding",
+		}
+	}
+
+	promptTokens := 0
+	if resp.Metadata != nil {
+		// BillableCharacterCount counts characters, not tokens; convert it
+		// with the same ~4 characters-per-token heuristic as estimateTokens.
+		promptTokens = (int(resp.Metadata.BillableCharacterCount) + 3) / 4
+	}
+
+	return &models.EmbeddingsResponse{
+		Object: "list",
+		Data:   data,
+		Model:  model,
+		Usage: models.Usage{
+			PromptTokens: promptTokens,
+			TotalTokens:  promptTokens,
+		},
+	}, nil
+}
@@ -13,13 +13,17 @@ import (
 	"google.golang.org/genai"
 )
 
-// StreamResponse streams Vertex AI responses as Server-Sent Events (SSE)
-// in OpenAI-compatible format
+// StreamResponse streams Vertex AI responses as Server-Sent Events (SSE) in
+// OpenAI-compatible format. config may be nil. When includeUsage is true
+// (OpenAI's stream_options.include_usage), one extra chunk with an empty
+// choices array and a populated Usage is emitted just before [DONE].
 func StreamResponse(
 	ctx context.Context,
 	client *genai.Client,
 	model string,
 	contents []*genai.Content,
+	config *genai.GenerateContentConfig,
+	includeUsage bool,
 	w http.ResponseWriter,
 ) error {
 	// Set SSE headers
@@ -35,9 +39,13 @@ func StreamResponse(
 
 	// Generate a unique ID for this completion
 	completionID := "chatcmpl-" + uuid.New().String()
+	sawToolCall := false
+	toolCallIndex := 0
+	var fullText string
+	var usage *models.Usage
 
 	// Call Vertex AI with streaming
-	iter := client.Models.GenerateContentStream(ctx, model, contents, nil)
+	iter := client.Models.GenerateContentStream(ctx, model, contents, config)
 
 	// Use range to iterate over the stream (new genai SDK API)
 	for resp, err := range iter {
@@ -46,11 +54,23 @@ func StreamResponse(
 			return fmt.Errorf("streaming error: %w", err)
 		}
 
+		if resp.UsageMetadata != nil {
+			usage = usageFromMetadata(resp.UsageMetadata)
+		}
+
 		// Process each candidate in the response
 		if resp.Candidates != nil {
 			for _, candidate := range resp.Candidates {
 				if candidate.Content != nil && candidate.Content.Parts != nil {
 					for _, part := range candidate.Content.Parts {
+						fullText += part.Text
+						delta := models.Delta{Content: part.Text}
+						if part.FunctionCall != nil {
+							sawToolCall = true
+							delta.ToolCalls = []models.ToolCall{toolCallFromFunctionCall(part.FunctionCall, toolCallIndex)}
+							toolCallIndex++
+						}
+
 						// Create SSE chunk
 						chunk := models.ChatCompletionChunk{
 							ID:      completionID,
@@ -59,10 +79,8 @@ func StreamResponse(
 							Model:   model,
 							Choices: []models.Choice{
 								{
-									Index: 0,
-									Delta: models.Delta{
-										Content: part.Text,
-									},
+									Index:        0,
+									Delta:        delta,
 									FinishReason: nil,
 								},
 							},
@@ -86,6 +104,9 @@ func StreamResponse(
 
 	// Send final chunk with finish_reason
 	finishReason := "stop"
+	if sawToolCall {
+		finishReason = "tool_calls"
+	}
 	finalChunk := models.ChatCompletionChunk{
 		ID:      completionID,
 		Object:  "chat.completion.chunk",
@@ -102,22 +123,42 @@ func StreamResponse(
 
 	data, _ := json.Marshal(finalChunk)
 	fmt.Fprintf(w, "data: %s\n\n", data)
+
+	if includeUsage {
+		if usage == nil {
+			usage = &models.Usage{CompletionTokens: estimateTokens(fullText)}
+			usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+		}
+		usageChunk := models.ChatCompletionChunk{
+			ID:      completionID,
+			Object:  "chat.completion.chunk",
+			Created: time.Now().Unix(),
+			Model:   model,
+			Choices: []models.Choice{},
+			Usage:   usage,
+		}
+		data, _ := json.Marshal(usageChunk)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+	}
+
 	fmt.Fprintf(w, "data: [DONE]\n\n")
 	flusher.Flush()
 
 	return nil
 }
 
-// NonStreamingResponse handles non-streaming chat completions
+// NonStreamingResponse handles non-streaming chat completions. config may be
+// nil.
 func NonStreamingResponse(
 	ctx context.Context,
 	client *genai.Client,
 	model string,
 	contents []*genai.Content,
+	config *genai.GenerateContentConfig,
 	w http.ResponseWriter,
 ) error {
 	// Call Vertex AI without streaming
-	resp, err := client.Models.GenerateContent(ctx, model, contents, nil)
+	resp, err := client.Models.GenerateContent(ctx, model, contents, config)
 	if err != nil {
 		return fmt.Errorf("generate content error: %w", err)
 	}
@@ -125,15 +166,38 @@ func NonStreamingResponse(
 	// Build complete response
 	completionID := "chatcmpl-" + uuid.New().String()
 	var fullText string
+	var toolCalls []models.ToolCall
+	finishReason := "stop"
 
 	if resp.Candidates != nil && len(resp.Candidates) > 0 {
 		candidate := resp.Candidates[0]
 		if candidate.Content != nil && candidate.Content.Parts != nil {
 			for _, part := range candidate.Content.Parts {
 				fullText += part.Text
+				if part.FunctionCall != nil {
+					toolCalls = append(toolCalls, toolCallFromFunctionCall(part.FunctionCall, len(toolCalls)))
+				}
 			}
 		}
 	}
+	if len(toolCalls) > 0 {
+		finishReason = "tool_calls"
+	}
+
+	var usage models.Usage
+	if resp.UsageMetadata != nil {
+		usage = *usageFromMetadata(resp.UsageMetadata)
+	} else {
+		// The SDK didn't report counts; fall back to local estimates from the
+		// prompt and response text.
+		for _, content := range contents {
+			for _, part := range content.Parts {
+				usage.PromptTokens += estimateTokens(part.Text)
+			}
+		}
+		usage.CompletionTokens = estimateTokens(fullText)
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
 
 	response := models.ChatCompletionResponse{
 		ID:      completionID,
@@ -144,14 +208,62 @@ func NonStreamingResponse(
 			{
 				Index: 0,
 				Message: models.Message{
-					Role:    "assistant",
-					Content: fullText,
+					Role:      "assistant",
+					Content:   fullText,
+					ToolCalls: toolCalls,
 				},
-				FinishReason: "stop",
+				FinishReason: finishReason,
 			},
 		},
+		Usage: usage,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	return json.NewEncoder(w).Encode(response)
 }
+
+// usageFromMetadata converts the genai SDK's UsageMetadata into our
+// OpenAI-shaped Usage.
+func usageFromMetadata(meta *genai.GenerateContentResponseUsageMetadata) *models.Usage {
+	return &models.Usage{
+		PromptTokens:     int(meta.PromptTokenCount),
+		CompletionTokens: int(meta.CandidatesTokenCount),
+		TotalTokens:      int(meta.TotalTokenCount),
+	}
+}
+
+// estimateTokens gives a rough token count when the SDK doesn't return
+// usage metadata, using OpenAI's commonly cited ~4 characters-per-token
+// heuristic for English text.
+func estimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	return (len(text) + 3) / 4
+}
+
+// toolCallFromFunctionCall converts a Vertex AI FunctionCall part into an
+// OpenAI-compatible ToolCall, JSON-encoding its arguments as required by the
+// wire format. index is this call's position among the parallel tool calls
+// in its choice (see ToolCall.Index).
+func toolCallFromFunctionCall(fc *genai.FunctionCall, index int) models.ToolCall {
+	args, err := json.Marshal(fc.Args)
+	if err != nil {
+		args = []byte("{}")
+	}
+
+	id := fc.ID
+	if id == "" {
+		id = "call_" + uuid.New().String()
+	}
+
+	return models.ToolCall{
+		ID:   id,
+		Type: "function",
+		Function: models.ToolCallFunction{
+			Name:      fc.Name,
+			Arguments: string(args),
+		},
+		Index: &index,
+	}
+}